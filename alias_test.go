@@ -0,0 +1,55 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type aliasRecord struct {
+	Email string `csv:"email,alias=email_address,alias=e-mail,required"`
+	Name  string `csv:"name,alias=full_name"`
+}
+
+func TestAliases(t *testing.T) {
+	t.Run("each schema", func(t *testing.T) {
+		for _, header := range []string{"email,name", "email_address,full_name", "e-mail,name"} {
+			require := testifyrequire.New(t)
+			reader := NewStructuredCSVReader[aliasRecord](strings.NewReader(header + "\na@example.com,alice\n"))
+			record, err := reader.Next()
+			require.NoError(err, header)
+			require.Equal(aliasRecord{Email: "a@example.com", Name: "alice"}, record, header)
+			require.Empty(reader.IgnoredColumns(), header)
+		}
+	})
+	t.Run("required column seen under an alias", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[aliasRecord](strings.NewReader("full_name\nalice\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "email is a required column but was not seen in the csv")
+	})
+	t.Run("case insensitive", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[aliasRecord](strings.NewReader("E-Mail,Full_Name\na@example.com,alice\n"))
+		reader.CaseInsensitiveHeaders = true
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(aliasRecord{Email: "a@example.com", Name: "alice"}, record)
+	})
+	t.Run("writes the primary name", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[aliasRecord](&buf)
+		require.NoError(writer.WriteRecord(aliasRecord{Email: "a@example.com", Name: "alice"}))
+		require.NoError(writer.Flush())
+		require.Equal("email,name\na@example.com,alice\n", buf.String())
+	})
+	t.Run("describe columns", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		columns := DescribeColumns[aliasRecord]()
+		require.Equal([]string{"email_address", "e-mail"}, columns[0].Aliases)
+		require.Equal([]string{"full_name"}, columns[1].Aliases)
+	})
+}
@@ -0,0 +1,31 @@
+package csv
+
+import (
+	"bufio"
+	"io"
+)
+
+// bomSkippingReader drops a UTF-8 byte-order mark from the start of a source, such as the one Excel writes.
+// Otherwise the mark would become part of the first header name.
+type bomSkippingReader struct {
+	src *bufio.Reader
+	// checked is set once the start of the source has been checked for the mark
+	checked bool
+}
+
+func newBOMSkippingReader(src io.Reader) *bomSkippingReader {
+	return &bomSkippingReader{src: bufio.NewReader(src)}
+}
+
+func (b *bomSkippingReader) Read(p []byte) (int, error) {
+	if !b.checked {
+		b.checked = true
+		// A short source can't start with the mark, any error is returned by the read below
+		if prefix, err := b.src.Peek(len(utf8BOM)); err == nil && string(prefix) == utf8BOM {
+			if _, err := b.src.Discard(len(utf8BOM)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return b.src.Read(p)
+}
@@ -0,0 +1,47 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+func TestReader_BOM(t *testing.T) {
+	t.Run("header", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](strings.NewReader(utf8BOM + "a_string,a_float,an_int\nstring,1.5,2\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(requiredCSVRecordStrictFail{AString: "string", AFloat: 1.5, AnInt: 2}, record)
+		require.Equal([]string{"a_string", "a_float", "an_int"}, reader.Headers())
+	})
+	t.Run("record terminator", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(utf8BOM + "a_string,an_int|string,2|"))
+		reader.RecordTerminator = '|'
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "string", AnInt: 2}, record)
+	})
+	t.Run("only the leading mark", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\n" + utf8BOM + "string,2\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(utf8BOM+"string", record.AString)
+	})
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[simpleCSVRecord](&buf)
+		writer.WriteBOM = true
+		require.NoError(writer.WriteRecord(simpleCSVRecord{AString: "é", AnInt: 1}))
+		require.NoError(writer.Flush())
+		reader := NewStructuredCSVReader[simpleCSVRecord](&buf)
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "é", AnInt: 1}, record)
+	})
+}
@@ -0,0 +1,73 @@
+package csv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// getBoolEncoder returns an encoder that writes a bool field as the first of the truevalues= or falsevalues= literals.
+// TRUE or FALSE is written if the matching option was not set.
+func getBoolEncoder(fieldType reflect.Type, fieldName string, trueValues, falseValues []string, omitEmpty bool) encoderFunction {
+	if fieldType.Kind() != reflect.Bool {
+		return func(val reflect.Value) (string, error) {
+			return "", fmt.Errorf("%v can only use truevalues=/falsevalues= with a bool, not %v", fieldName, fieldType)
+		}
+	}
+	trueLiteral, falseLiteral := "TRUE", "FALSE"
+	if len(trueValues) > 0 {
+		trueLiteral = trueValues[0]
+	}
+	if len(falseValues) > 0 {
+		falseLiteral = falseValues[0]
+	}
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		if val.Bool() {
+			return trueLiteral, nil
+		}
+		return falseLiteral, nil
+	}
+}
+
+// getBoolDecoder returns a decoder that matches a bool field against the truevalues= and falsevalues= literals, ignoring case.
+// Cells that match neither fall back to strconv.ParseBool.
+func getBoolDecoder(fieldType reflect.Type, fieldName string, trueValues, falseValues []string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	if fieldType.Kind() != reflect.Bool {
+		return func(s string, isNull bool) (any, error) {
+			return nil, fmt.Errorf("%v can only use truevalues=/falsevalues= with a bool, not %v", fieldName, fieldType)
+		}
+	}
+	matches := func(s string, literals []string) bool {
+		for _, literal := range literals {
+			if strings.EqualFold(s, literal) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		var b bool
+		switch {
+		case len(s) == 0:
+		case matches(s, trueValues):
+			b = true
+		case matches(s, falseValues):
+		default:
+			var err error
+			if b, err = strconv.ParseBool(s); err != nil {
+				return nil, err
+			}
+		}
+		// Named bool types need to be converted back to the field type
+		return reflect.ValueOf(b).Convert(fieldType).Interface(), nil
+	}
+}
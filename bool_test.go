@@ -0,0 +1,64 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type boolLiteralRecord struct {
+	Active  bool `csv:"active,truevalues=yes|y,falsevalues=no|n"`
+	Enabled bool `csv:"enabled,truevalues=1,falsevalues=0"`
+	// Only the true literal is set, false is still written as FALSE
+	Flag bool `csv:"flag,truevalues=on"`
+}
+
+type misappliedBoolLiteralRecord struct {
+	Name string `csv:"name,truevalues=yes"`
+}
+
+func TestBoolLiterals(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		records := []boolLiteralRecord{
+			{Active: true, Enabled: true, Flag: true},
+			{},
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[boolLiteralRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("active,enabled,flag\nyes,1,on\nno,0,FALSE\n", buf.String())
+
+		reader := NewStructuredCSVReader[boolLiteralRecord](&buf)
+		out, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal(records, out)
+	})
+	t.Run("alternate literals and fallback", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[boolLiteralRecord](strings.NewReader(
+			"active,enabled,flag\nY,0,true\nN,1,\n",
+		))
+		out, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal([]boolLiteralRecord{
+			{Active: true, Flag: true},
+			{Enabled: true},
+		}, out)
+	})
+	t.Run("unknown literal", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[boolLiteralRecord](strings.NewReader("active,enabled,flag\nmaybe,1,on\n"))
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "active": strconv.ParseBool: parsing "maybe": invalid syntax`)
+	})
+	t.Run("non-bool field", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[misappliedBoolLiteralRecord](strings.NewReader("name\nyes\n"))
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "name": name can only use truevalues=/falsevalues= with a bool, not string`)
+	})
+}
@@ -0,0 +1,129 @@
+package csv
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// Binary formats for []byte fields, base64 is used when no format is given
+const (
+	bytesFormatHex    = "hex"
+	bytesFormatBase64 = "base64"
+	bytesFormatRaw    = "raw"
+)
+
+// isBytesFormat checks if a format is one of the binary formats.
+func isBytesFormat(format string) bool {
+	return format == bytesFormatHex || format == bytesFormatBase64 || format == bytesFormatRaw
+}
+
+// errInvalidBytesFormat is the error for an encoding= value that isn't a binary format.
+func errInvalidBytesFormat(fieldName string, format string) error {
+	return fmt.Errorf("%v has an invalid encoding= value %q, expected hex, base64, or raw", fieldName, format)
+}
+
+// isBytesType checks if a type is a byte slice.
+func isBytesType(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Uint8
+}
+
+// getBytesEncoder returns an encoder that writes a []byte field in a binary format such as hex.
+// A nil or empty slice is always written as null.
+func getBytesEncoder(fieldType reflect.Type, fieldName string, format string) encoderFunction {
+	if !isBytesFormat(format) {
+		return func(val reflect.Value) (string, error) {
+			return "", errInvalidBytesFormat(fieldName, format)
+		}
+	}
+	if !isBytesType(fieldType) {
+		return func(val reflect.Value) (string, error) {
+			return "", fmt.Errorf("%v can only use %v with []byte, not %v", fieldName, format, fieldType)
+		}
+	}
+	return func(val reflect.Value) (string, error) {
+		if val.Len() == 0 {
+			return "", nil
+		}
+		switch format {
+		case bytesFormatHex:
+			return hex.EncodeToString(val.Bytes()), nil
+		case bytesFormatRaw:
+			return string(val.Bytes()), nil
+		}
+		return base64.StdEncoding.EncodeToString(val.Bytes()), nil
+	}
+}
+
+// getBytesDecoder returns a decoder that parses a []byte field from a binary format such as hex.
+// An empty cell yields a nil slice.
+func getBytesDecoder(fieldType reflect.Type, fieldName string, format string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	if !isBytesFormat(format) {
+		return func(s string, isNull bool) (any, error) {
+			return nil, errInvalidBytesFormat(fieldName, format)
+		}
+	}
+	if !isBytesType(fieldType) {
+		return func(s string, isNull bool) (any, error) {
+			return nil, fmt.Errorf("%v can only use %v with []byte, not %v", fieldName, format, fieldType)
+		}
+	}
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		if len(s) == 0 {
+			return reflect.Zero(fieldType).Interface(), nil
+		}
+		var data []byte
+		var err error
+		switch format {
+		case bytesFormatHex:
+			data, err = hex.DecodeString(s)
+		case bytesFormatRaw:
+			data = []byte(s)
+		default:
+			data, err = base64.StdEncoding.DecodeString(s)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v is not valid %v: %w", fieldName, format, err)
+		}
+		// Named byte slice types need to be converted back to the field type
+		return reflect.ValueOf(data).Convert(fieldType).Interface(), nil
+	}
+}
+
+var tOfRunes = reflect.TypeFor[[]rune]()
+
+// isRunesType checks if a type is a rune slice.
+func isRunesType(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Int32
+}
+
+// getRunesEncoder returns an encoder that writes a []rune field as a plain string.
+func getRunesEncoder(fieldType reflect.Type, omitEmpty bool) encoderFunction {
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		// Named rune slice types need to be converted to []rune
+		return string(val.Convert(tOfRunes).Interface().([]rune)), nil
+	}
+}
+
+// getRunesDecoder returns a decoder that reads a []rune field from a plain string, an empty cell yields a nil slice.
+func getRunesDecoder(fieldType reflect.Type, fieldName string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		if len(s) == 0 {
+			return reflect.Zero(fieldType).Interface(), nil
+		}
+		return reflect.ValueOf([]rune(s)).Convert(fieldType).Interface(), nil
+	}
+}
@@ -0,0 +1,100 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type bytesRecord struct {
+	Hex    []byte `csv:"hex,hex"`
+	Base64 []byte `csv:"base64,base64,omitempty"`
+}
+
+type bytesEncodingRecord struct {
+	Default []byte `csv:"default"`
+	Hex     []byte `csv:"hex,encoding=hex"`
+	Raw     []byte `csv:"raw,encoding=raw"`
+	Runes   []rune `csv:"runes"`
+}
+
+type invalidBytesEncodingRecord struct {
+	Data []byte `csv:"data,encoding=base32"`
+}
+
+type conflictingBytesEncodingRecord struct {
+	Data []byte `csv:"data,hex,encoding=raw"`
+}
+
+type conflictingBytesRecord struct {
+	ID []byte `csv:"id,hex,base64"`
+}
+
+func TestBytes(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		records := []bytesRecord{
+			{Hex: []byte{0xde, 0xad, 0xbe, 0xef}, Base64: []byte("hello")},
+			{Hex: []byte{}, Base64: nil},
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[bytesRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("hex,base64\ndeadbeef,aGVsbG8=\n,\n", buf.String())
+
+		reader := NewStructuredCSVReader[bytesRecord](&buf)
+		reader.SkipTrailingBlankLines = false
+		out, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal([]bytesRecord{
+			{Hex: []byte{0xde, 0xad, 0xbe, 0xef}, Base64: []byte("hello")},
+			{},
+		}, out)
+	})
+	t.Run("odd length hex", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[bytesRecord](strings.NewReader("hex\nabc\n"))
+		_, err := reader.Next()
+		require.ErrorContains(err, "on row 2: column \"hex\": hex is not valid hex: encoding/hex: odd length hex string")
+	})
+	t.Run("mutually exclusive", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[conflictingBytesRecord](strings.NewReader("id\nab\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"id\": id can not use both hex and base64")
+		writer := NewWriter[conflictingBytesRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(conflictingBytesRecord{}), "id can not use both hex and base64")
+	})
+	t.Run("encoding", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		records := []bytesEncodingRecord{
+			{Default: []byte("hello"), Hex: []byte{0xca, 0xfe}, Raw: []byte("plain text"), Runes: []rune("héllo, 世界")},
+			{},
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[bytesEncodingRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("default,hex,raw,runes\naGVsbG8=,cafe,plain text,\"héllo, 世界\"\n,,,\n", buf.String())
+
+		reader := NewStructuredCSVReader[bytesEncodingRecord](&buf)
+		reader.SkipTrailingBlankLines = false
+		out, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal(records, out)
+	})
+	t.Run("invalid encoding", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[invalidBytesEncodingRecord](strings.NewReader("data\nab\n"))
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "data": data has an invalid encoding= value "base32", expected hex, base64, or raw`)
+	})
+	t.Run("encoding conflicts with a format", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[conflictingBytesEncodingRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(conflictingBytesEncodingRecord{}), "data can not use both hex and encoding=raw")
+	})
+}
@@ -16,7 +16,11 @@ var tOfTextMarshaller = reflect.TypeFor[encoding.TextMarshaler]()
 var tOfStringer = reflect.TypeFor[Stringer]()
 var tOfUnmarshalCSV = reflect.TypeFor[UnmarshalCSV]()
 var tOfTextUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+var tOfCSVNullable = reflect.TypeFor[CSVNullable]()
+var tOfFromStringer = reflect.TypeFor[FromStringer]()
 var tOfZeroer = reflect.TypeFor[Zeroer]()
+var tOfError = reflect.TypeFor[error]()
+var tOfString = reflect.TypeFor[string]()
 
 // encoderFunction is what is used to take a value and encode it into a string response for the CSV
 type encoderFunction func(val reflect.Value) (string, error)
@@ -37,14 +41,39 @@ func isZeroZeroer(value reflect.Value) bool {
 	return value.Interface().(Zeroer).IsZero()
 }
 
-// getEncoderProvider returns a memoized function for encoding values based on their scalar types.
-// structs, slices, and maps are not supported natively and should implement a MarshalCSV interface.
-func getEncoderProvider(fieldType reflect.Type, omitEmpty bool) encoderFunction {
-	var zeroerFunc zeroValueFunction = isZero
+// isScalarKind checks if a type (or the type it points to) is a natively supported scalar.
+func isScalarKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// getZeroFunction returns the zero detection to use for a given type, a function from RegisterZeroFunc takes precedence.
+func getZeroFunction(fieldType reflect.Type) zeroValueFunction {
 	if fieldType.Implements(tOfZeroer) {
 		// Use the interface resolver rather than the reflection library
-		zeroerFunc = isZeroZeroer
+		return getRegisteredZeroFunc(fieldType, isZeroZeroer)
 	}
+	return getRegisteredZeroFunc(fieldType, isZero)
+}
+
+// getEncoderProvider returns a memoized function for encoding values based on their scalar types.
+// structs, slices, and maps are not supported natively and should implement a MarshalCSV interface or use RegisterCodec.
+func getEncoderProvider(fieldType reflect.Type, omitEmpty bool) encoderFunction {
+	return getRegisteredEncoder(fieldType, omitEmpty, getBuiltinEncoder(fieldType, omitEmpty))
+}
+
+// getBuiltinEncoder returns the encoder for the marshal interfaces and scalar types, used when no codec is registered.
+func getBuiltinEncoder(fieldType reflect.Type, omitEmpty bool) encoderFunction {
+	zeroerFunc := getZeroFunction(fieldType)
 	// Check to see if MarshalCSV is implemented
 	if fieldType.Implements(tOfMarshalCSV) {
 		return func(val reflect.Value) (string, error) {
@@ -68,10 +97,14 @@ func getEncoderProvider(fieldType reflect.Type, omitEmpty bool) encoderFunction
 			if omitEmpty && zeroerFunc(val) {
 				return "", nil
 			}
-			out, err := val.Interface().(encoding.TextMarshaler).MarshalText()
-			return string(out), err
+			return val.Interface().(Stringer).String(), nil
 		}
 	}
+	if isBytesType(fieldType) {
+		return getBytesEncoder(fieldType, "", bytesFormatBase64)
+	} else if isRunesType(fieldType) {
+		return getRunesEncoder(fieldType, omitEmpty)
+	}
 	if fieldType.Kind() == reflect.Ptr {
 		fieldType = fieldType.Elem()
 	}
@@ -103,7 +136,15 @@ func getEncoderProvider(fieldType reflect.Type, omitEmpty bool) encoderFunction
 			// All uints can be accessed via Unit
 			return strconv.FormatUint(val.Uint(), 10), nil
 		}
-	case reflect.Float32, reflect.Float64:
+	case reflect.Float32:
+		return func(val reflect.Value) (string, error) {
+			if omitEmpty && zeroerFunc(val) {
+				return "", nil
+			}
+			// Format with the 32-bit size so widening to a float64 doesn't add digits (5125.23 not 5125.22998046875)
+			return strconv.FormatFloat(val.Float(), 'f', -1, 32), nil
+		}
+	case reflect.Float64:
 		return func(val reflect.Value) (string, error) {
 			if omitEmpty && zeroerFunc(val) {
 				return "", nil
@@ -122,15 +163,20 @@ func getEncoderProvider(fieldType reflect.Type, omitEmpty bool) encoderFunction
 		}
 	default:
 		return func(val reflect.Value) (string, error) {
-			return "", fmt.Errorf("can not serialize type %v", fieldType.Kind())
+			return "", &UnsupportedTypeError{Type: fieldType}
 		}
 	}
 }
 
 // getDecoderProvider returns a memoized function for decoding values based on their scalar types.
-// structs, slices, and maps are not supported natively and should implement an UnmarshalCSV interface.
+// structs, slices, and maps are not supported natively and should implement an UnmarshalCSV interface or use RegisterCodec.
 func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool) decoderFunction {
-	var errFieldRequired = fmt.Errorf("%v is a required field", fieldName)
+	return getRegisteredDecoder(fieldType, fieldName, required, getBuiltinDecoder(fieldType, fieldName, required))
+}
+
+// getBuiltinDecoder returns the decoder for the unmarshal interfaces and scalar types, used when no codec is registered.
+func getBuiltinDecoder(fieldType reflect.Type, fieldName string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
 	if fieldType.Kind() != reflect.Ptr {
 		// Create a pointer for a value type to assert if an interface can be applied
 		fieldType = reflect.New(fieldType).Type()
@@ -153,6 +199,21 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 			}
 			return ref.Interface(), err
 		}
+	} else if fieldType.Implements(tOfCSVNullable) {
+		return func(s string, isNull bool) (any, error) {
+			if required && isNull {
+				return nil, errFieldRequired
+			}
+			data := reflect.New(fieldType.Elem())
+			var err error
+			if isNull {
+				data.Interface().(CSVNullable).SetNull()
+			} else {
+				err = data.Interface().(CSVNullable).SetValue(s)
+			}
+			// See comments for fieldType.Implements(tOfUnmarshalCSV)
+			return data.Elem().Interface(), err
+		}
 	} else if fieldType.Implements(tOfTextUnmarshaler) {
 		return func(s string, isNull bool) (any, error) {
 			if required && isNull {
@@ -167,7 +228,49 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 			}
 			return ref.Interface(), err
 		}
+	} else if fieldType.Implements(tOfFromStringer) {
+		return func(s string, isNull bool) (any, error) {
+			if required && isNull {
+				return nil, errFieldRequired
+			}
+			data := reflect.New(fieldType.Elem())
+			err := data.Interface().(FromStringer).FromString(s)
+			// See comments for fieldType.Implements(tOfUnmarshalCSV)
+			return data.Elem().Interface(), err
+		}
+	}
+	if isBytesType(fieldType.Elem()) {
+		return getBytesDecoder(fieldType.Elem(), fieldName, bytesFormatBase64, required)
+	} else if isRunesType(fieldType.Elem()) {
+		return getRunesDecoder(fieldType.Elem(), fieldName, required)
 	}
+	decoder := getKindDecoder(fieldType, errFieldRequired, required)
+	if elemType := fieldType.Elem(); len(elemType.PkgPath()) > 0 && isScalarKind(elemType) {
+		return getNamedScalarDecoder(elemType, decoder)
+	}
+	return decoder
+}
+
+// getNamedScalarDecoder wraps the decoder for the underlying kind of a named scalar type (e.g. type Color string).
+// The decoded value is converted to the named type so it can be set on the field, registered enums are validated here.
+func getNamedScalarDecoder(namedType reflect.Type, decoder decoderFunction) decoderFunction {
+	return func(s string, isNull bool) (any, error) {
+		val, err := decoder(s, isNull)
+		if err != nil {
+			return val, err
+		}
+		converted := reflect.ValueOf(val).Convert(namedType)
+		if !isNull {
+			if err := checkEnum(namedType, converted); err != nil {
+				return nil, err
+			}
+		}
+		return converted.Interface(), nil
+	}
+}
+
+// getKindDecoder returns the decoder for the scalar kind of the type fieldType points to.
+func getKindDecoder(fieldType reflect.Type, errFieldRequired error, required bool) decoderFunction {
 	switch fieldType.Elem().Kind() {
 	case reflect.String:
 		return func(s string, isNull bool) (any, error) {
@@ -193,7 +296,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return int8(0), nil
 			}
 			val, err := strconv.ParseInt(s, 10, 8)
 			return int8(val), err
@@ -204,7 +307,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return int16(0), nil
 			}
 			val, err := strconv.ParseInt(s, 10, 16)
 			return int16(val), err
@@ -215,7 +318,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return int32(0), nil
 			}
 			val, err := strconv.ParseInt(s, 10, 32)
 			return int32(val), err
@@ -226,7 +329,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return int64(0), nil
 			}
 			return strconv.ParseInt(s, 10, 64)
 		}
@@ -236,7 +339,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return uint(0), nil
 			}
 			val, err := strconv.ParseUint(s, 10, strconv.IntSize)
 			return uint(val), err
@@ -247,7 +350,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return uint8(0), nil
 			}
 			val, err := strconv.ParseUint(s, 10, 8)
 			return uint8(val), err
@@ -258,7 +361,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return uint16(0), nil
 			}
 			val, err := strconv.ParseUint(s, 10, 16)
 			return uint16(val), err
@@ -269,7 +372,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return uint32(0), nil
 			}
 			val, err := strconv.ParseUint(s, 10, 32)
 			return uint32(val), err
@@ -280,7 +383,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return uint64(0), nil
 			}
 			return strconv.ParseUint(s, 10, 64)
 		}
@@ -290,7 +393,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return float32(0), nil
 			}
 			f, err := strconv.ParseFloat(s, 32)
 			return float32(f), err
@@ -301,7 +404,7 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 				return nil, errFieldRequired
 			}
 			if len(s) == 0 {
-				return 0, nil
+				return float64(0), nil
 			}
 			f, err := strconv.ParseFloat(s, 64)
 			return f, err
@@ -318,18 +421,80 @@ func getDecoderProvider(fieldType reflect.Type, fieldName string, required bool)
 		}
 	default:
 		return func(s string, isNull bool) (any, error) {
-			return "", fmt.Errorf("can not unserialize type %v", fieldType.Kind())
+			return "", &UnsupportedTypeError{Type: fieldType.Elem(), decoding: true}
+		}
+	}
+}
+
+// getMethodEncoder returns an encoder that calls the named method on the value.
+// The method must take no arguments and return either (string, error) or string.
+// If the type does not have a usable method with that name, the default encoder is returned.
+func getMethodEncoder(fieldType reflect.Type, methodName string, omitEmpty bool) encoderFunction {
+	method, ok := reflect.PointerTo(fieldType).MethodByName(methodName)
+	if !ok || !isStringMethod(method.Type) {
+		return getEncoderProvider(fieldType, omitEmpty)
+	}
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		if !val.CanAddr() {
+			// Pointer receivers need an addressable copy of the value
+			tmp := reflect.New(fieldType).Elem()
+			tmp.Set(val)
+			val = tmp
+		}
+		out := val.Addr().MethodByName(methodName).Call(nil)
+		if len(out) == 2 && !out[1].IsNil() {
+			return "", out[1].Interface().(error)
+		}
+		return out[0].String(), nil
+	}
+}
+
+// isStringMethod checks if a method signature (including the receiver) is func() string or func() (string, error).
+func isStringMethod(methodType reflect.Type) bool {
+	if methodType.NumIn() != 1 || methodType.NumOut() == 0 || methodType.NumOut() > 2 {
+		return false
+	}
+	if methodType.Out(0).Kind() != reflect.String {
+		return false
+	}
+	return methodType.NumOut() == 1 || methodType.Out(1) == tOfError
+}
+
+// getMethodDecoder returns a decoder that calls the named method on a new value to decode it.
+// The method must take a single string and return an error, much like UnmarshalCSV.
+// If the type does not have a usable method with that name, the default decoder is returned.
+func getMethodDecoder(fieldType reflect.Type, methodName string, fieldName string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	method, ok := reflect.PointerTo(fieldType).MethodByName(methodName)
+	if !ok || method.Type.NumIn() != 2 || method.Type.In(1).Kind() != reflect.String ||
+		method.Type.NumOut() != 1 || method.Type.Out(0) != tOfError {
+		return getDecoderProvider(fieldType, fieldName, required)
+	}
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
 		}
+		data := reflect.New(fieldType)
+		out := data.MethodByName(methodName).Call([]reflect.Value{reflect.ValueOf(s).Convert(method.Type.In(1))})
+		if !out[0].IsNil() {
+			return nil, out[0].Interface().(error)
+		}
+		return data.Elem().Interface(), nil
 	}
 }
 
 // tagParts is a quick helper type for parsing the extra tag arguments.
 type tagParts []string
 
+// Find looks up a sub tag, a key ending in = matches any value otherwise the key must match exactly (or be followed by =).
 func (tp tagParts) Find(key string) (string, bool) {
 	for _, v := range tp {
 		// Loop through to find the sub tag
-		if strings.HasPrefix(v, key) {
+		if strings.HasSuffix(key, "=") && strings.HasPrefix(v, key) || v == key || strings.HasPrefix(v, key+"=") {
 			kv := strings.SplitN(v, "=", 2)
 			if len(kv) == 1 {
 				// Present but has no value
@@ -342,6 +507,31 @@ func (tp tagParts) Find(key string) (string, bool) {
 	return "", false
 }
 
+// FindAll looks up every value of a sub tag that can be repeated, such as alias=.
+func (tp tagParts) FindAll(key string) []string {
+	var out []string
+	for _, v := range tp {
+		if strings.HasPrefix(v, key) {
+			out = append(out, strings.TrimPrefix(v, key))
+		}
+	}
+	return out
+}
+
+// tagListSeparator separates the values of tag options that take a list, such as truevalues= and null=
+const tagListSeparator = "|"
+
+// splitTagList splits the value of a tag option that takes a list, dropping empty values.
+func splitTagList(values string) []string {
+	var out []string
+	for _, value := range strings.Split(values, tagListSeparator) {
+		if len(value) > 0 {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
 // Ensure that the csvInstruction can be used by rcache.
 var _ rcache.InstructionSet = (*csvInstruction)(nil)
 
@@ -350,6 +540,31 @@ type csvInstruction struct {
 	encoder           encoderFunction
 	decoder           decoderFunction
 	exportedFieldName string
+	required          bool
+	omitEmpty         bool
+	// lineNo is set for fields that are filled with the row number rather than a column
+	lineNo bool
+	// extras is set for the map field that holds the columns no other field maps to
+	extras bool
+	// extrasErr is set when the extras tag option is used on a field that can't hold the columns, it's reported by the record's layout
+	extrasErr error
+	// defaultValue is decoded for null cells and missing columns when hasDefault is set
+	defaultValue string
+	hasDefault   bool
+	// structFieldName is the name of the struct field, used to match a header when the tag has no name
+	structFieldName string
+	// embedded is set for embedded structs whose fields are promoted into the record rather than being a column
+	embedded bool
+	// nullValues are the null= tag option sentinels that are read as a null cell
+	nullValues []string
+	// columnIndex is the raw index= tag value that positions the column, it is parsed when the record's layout is built
+	columnIndex string
+	// aliases are alternate header names that are read into this field, only the exported field name is written
+	aliases []string
+	// nested is set for struct fields that are encoded as a CSV of their own in one cell
+	nested bool
+	// ownFormat is set when the tags pick the field's format (e.g. format= or truevalues=), a Locale leaves these cells alone
+	ownFormat bool
 }
 
 // GetCSVHeaderIdentifier gets the mapping identifier for the CSV header.
@@ -380,21 +595,254 @@ func (c csvInstruction) Skip(tag string) bool {
 func (c csvInstruction) GetMetadata(field reflect.StructField, tag string) rcache.InstructionSet {
 	var omitEmpty bool
 	var required bool
+	var encodeMethod, decodeMethod string
+	var pairSep, kvSep = defaultMapPairSeparator, defaultMapKVSeparator
+	var minVal, maxVal string
+	var durationFormat string
+	var unixPrecision string
+	var hasLayout bool
+	var layout string
+	var prec string
+	var smartPrec bool
+	var floatFormat string
+	var bytesFormat string
+	// bytesFormatConflict names the options when more than one binary format is set
+	var bytesFormatConflict string
+	var nested bool
+	var trimCutset string
+	var defaultValue string
+	var hasDefault bool
+	var lineNo bool
+	var extras bool
+	var trueValues, falseValues []string
+	var aliases []string
+	var columnIndex string
+	var nullValues []string
 	parts := tagParts(strings.Split(tag, ","))
 	if len(parts) > 1 {
 		// Skip past the field name declaration.
 		parts = parts[1:]
 		_, omitEmpty = parts.Find("omitempty")
 		_, required = parts.Find("required")
+		encodeMethod, _ = parts.Find("method=")
+		decodeMethod, _ = parts.Find("setmethod=")
+		if sep, ok := parts.Find("sep="); ok && len(sep) > 0 {
+			pairSep = sep
+		}
+		if sep, ok := parts.Find("kv="); ok && len(sep) > 0 {
+			kvSep = sep
+		}
+		minVal, _ = parts.Find("min=")
+		maxVal, _ = parts.Find("max=")
+		durationFormat, _ = parts.Find("duration=")
+		if _, ok := parts.Find(timeUnixSeconds); ok {
+			unixPrecision = timeUnixSeconds
+		}
+		if _, ok := parts.Find(timeUnixMilliseconds); ok {
+			unixPrecision = timeUnixMilliseconds
+		}
+		// layout= is an alias of format=
+		layout, hasLayout = parts.Find("format=")
+		if !hasLayout {
+			layout, hasLayout = parts.Find("layout=")
+		}
+		// precision= is an alias of prec=
+		prec, _ = parts.Find("prec=")
+		if len(prec) == 0 {
+			prec, _ = parts.Find("precision=")
+		}
+		floatFormat, _ = parts.Find("fmt=")
+		_, smartPrec = parts.Find("smartprec")
+		_, nested = parts.Find("nested")
+		trimCutset, _ = parts.Find("trim=")
+		defaultValue, hasDefault = parts.Find("default")
+		_, lineNo = parts.Find("lineno")
+		_, extras = parts.Find("extras")
+		columnIndex, _ = parts.Find("index=")
+		if values, ok := parts.Find("null="); ok {
+			nullValues = splitTagList(values)
+		}
+		for _, alias := range parts.FindAll("alias=") {
+			if len(alias) > 0 {
+				aliases = append(aliases, alias)
+			}
+		}
+		if values, ok := parts.Find("truevalues="); ok {
+			trueValues = splitTagList(values)
+		}
+		if values, ok := parts.Find("falsevalues="); ok {
+			falseValues = splitTagList(values)
+		}
+		if _, ok := parts.Find(bytesFormatHex); ok {
+			bytesFormat = bytesFormatHex
+		}
+		if _, ok := parts.Find(bytesFormatBase64); ok {
+			if len(bytesFormat) > 0 {
+				bytesFormatConflict = "hex and base64"
+			}
+			bytesFormat = bytesFormatBase64
+		}
+		if encoding, ok := parts.Find("encoding="); ok {
+			if len(bytesFormat) > 0 && bytesFormat != encoding && len(bytesFormatConflict) == 0 {
+				bytesFormatConflict = fmt.Sprintf("%v and encoding=%v", bytesFormat, encoding)
+			}
+			bytesFormat = encoding
+		}
 	}
+	isDuration := len(durationFormat) > 0 && (field.Type == tOfDuration || field.Type == reflect.PointerTo(tOfDuration))
 	var instruction csvInstruction
 	fieldName := c.FieldName(tag)
-	instruction.encoder = getEncoderProvider(field.Type, omitEmpty)
-	instruction.decoder = getDecoderProvider(field.Type, fieldName, required)
-	c.exportedFieldName = fieldName
+	if len(unixPrecision) > 0 && hasLayout {
+		instruction.encoder, instruction.decoder = getErrorCodec(fmt.Errorf("%v can not use both %v and format=", fieldName, unixPrecision))
+		instruction.exportedFieldName = fieldName
+		return instruction
+	}
+	if isMapField(field.Type) && field.Type.Elem().Kind() == reflect.Ptr {
+		instruction.encoder, instruction.decoder = getErrorCodec(fmt.Errorf("%v is a %v, map values can not be pointers", fieldName, field.Type))
+		instruction.exportedFieldName = fieldName
+		return instruction
+	}
+	if len(bytesFormatConflict) > 0 {
+		instruction.encoder, instruction.decoder = getErrorCodec(fmt.Errorf("%v can not use both %v", fieldName, bytesFormatConflict))
+		instruction.exportedFieldName = fieldName
+		return instruction
+	}
+	switch {
+	case len(encodeMethod) > 0:
+		instruction.encoder = getMethodEncoder(field.Type, encodeMethod, omitEmpty)
+	case isMapField(field.Type):
+		instruction.encoder = getMapEncoder(field.Type, pairSep, kvSep)
+	case isDuration:
+		instruction.encoder = getDurationEncoder(field.Type, durationFormat, omitEmpty)
+	case len(unixPrecision) > 0:
+		instruction.encoder = getUnixTimeEncoder(field.Type, unixPrecision, omitEmpty)
+	case isTimeField(field.Type):
+		instruction.encoder = getTimeEncoder(field.Type, layout, omitEmpty)
+	case nested:
+		instruction.encoder = getNestedEncoder(field.Type, fieldName, omitEmpty)
+	case len(bytesFormat) > 0:
+		instruction.encoder = getBytesEncoder(field.Type, fieldName, bytesFormat)
+	case len(prec) > 0 || len(floatFormat) > 0 || smartPrec:
+		instruction.encoder = getFloatEncoder(field.Type, fieldName, prec, floatFormat, smartPrec, omitEmpty)
+	case len(trueValues) > 0 || len(falseValues) > 0:
+		instruction.encoder = getBoolEncoder(field.Type, fieldName, trueValues, falseValues, omitEmpty)
+	default:
+		instruction.encoder = getEncoderProvider(field.Type, omitEmpty)
+	}
+	switch {
+	case len(decodeMethod) > 0:
+		instruction.decoder = getMethodDecoder(field.Type, decodeMethod, fieldName, required)
+	case isMapField(field.Type):
+		instruction.decoder = getMapDecoder(field.Type, fieldName, required, pairSep, kvSep)
+	case isDuration:
+		instruction.decoder = getDurationDecoder(field.Type, fieldName, durationFormat, required)
+	case len(unixPrecision) > 0:
+		instruction.decoder = getUnixTimeDecoder(field.Type, fieldName, unixPrecision, required)
+	case isTimeField(field.Type):
+		instruction.decoder = getTimeDecoder(field.Type, fieldName, layout, required)
+	case nested:
+		instruction.decoder = getNestedDecoder(field.Type, fieldName, required)
+	case len(bytesFormat) > 0:
+		instruction.decoder = getBytesDecoder(field.Type, fieldName, bytesFormat, required)
+	case len(trueValues) > 0 || len(falseValues) > 0:
+		instruction.decoder = getBoolDecoder(field.Type, fieldName, trueValues, falseValues, required)
+	default:
+		instruction.decoder = getDecoderProvider(field.Type, fieldName, required)
+	}
+	if len(minVal) > 0 || len(maxVal) > 0 {
+		instruction.decoder = getRangeDecoder(instruction.decoder, field.Type, fieldName, minVal, maxVal)
+	}
+	if hasDefault {
+		instruction.decoder = getDefaultDecoder(instruction.decoder, fieldName, defaultValue, required)
+	}
+	if len(trimCutset) > 0 {
+		// Trimming runs first so every other decoder sees the cleaned cell
+		instruction.decoder = getTrimDecoder(instruction.decoder, trimCutset)
+	}
+	instruction.exportedFieldName = fieldName
+	instruction.required = required
+	instruction.omitEmpty = omitEmpty
+	instruction.nested = nested
+	instruction.ownFormat = len(encodeMethod) > 0 || len(decodeMethod) > 0 || hasLayout || len(unixPrecision) > 0 ||
+		len(trueValues) > 0 || len(falseValues) > 0 || isDuration
+	instruction.defaultValue = defaultValue
+	instruction.hasDefault = hasDefault
+	instruction.lineNo = lineNo
+	instruction.extras = extras
+	if extras && !isExtrasMap(field.Type) {
+		instruction.extrasErr = fmt.Errorf("%v is tagged extras but is a %v rather than a map[string]string", field.Name, field.Type)
+	}
+	instruction.aliases = aliases
+	instruction.columnIndex = columnIndex
+	instruction.nullValues = nullValues
+	instruction.structFieldName = field.Name
+	instruction.embedded = isPromotedStruct(field, tag)
 	return instruction
 }
 
+// IsRequired reports if the field was tagged as required.
+func (c csvInstruction) IsRequired() bool {
+	return c.required
+}
+
+// GetDefault gets the default= tag value and whether the field has one.
+func (c csvInstruction) GetDefault() (string, bool) {
+	return c.defaultValue, c.hasDefault
+}
+
+// lookupName gets the name used to match the field to a header, which is the struct field name if the tag has no name.
+func (c csvInstruction) lookupName() string {
+	if len(c.exportedFieldName) > 0 {
+		return c.exportedFieldName
+	}
+	return c.structFieldName
+}
+
+// IsEmbedded reports if the field is an embedded struct whose fields are promoted into the record.
+func (c csvInstruction) IsEmbedded() bool {
+	return c.embedded
+}
+
+// GetAliases gets the alternate header names from the alias= tag options.
+func (c csvInstruction) GetAliases() []string {
+	return c.aliases
+}
+
+// GetNullValues gets the sentinels from the null= tag option.
+func (c csvInstruction) GetNullValues() []string {
+	return c.nullValues
+}
+
+// IsLineNo reports if the field was tagged with lineno, these are filled with the row number instead of a column.
+func (c csvInstruction) IsLineNo() bool {
+	return c.lineNo
+}
+
+// IsExtras reports if the field was tagged with extras, it holds the columns that no other field maps to.
+func (c csvInstruction) IsExtras() bool {
+	return c.extras
+}
+
+// IsNested reports if the field was tagged with nested, it is encoded as a CSV of its own in one cell.
+func (c csvInstruction) IsNested() bool {
+	return c.nested
+}
+
+// isLocalizable reports if a Locale applies to the field, which is when neither its tags nor RegisterCodec pick its format.
+// The registry is checked on every call since codecs can be registered after the instructions are cached.
+func (c csvInstruction) isLocalizable(fieldType reflect.Type) bool {
+	if c.ownFormat {
+		return false
+	}
+	_, ok := getRegisteredCodec(fieldType)
+	return !ok
+}
+
+// IsOmitEmpty reports if the field was tagged with omitempty.
+func (c csvInstruction) IsOmitEmpty() bool {
+	return c.omitEmpty
+}
+
 // GetDecoder gets the decoder for a given field.
 func (c csvInstruction) GetDecoder() decoderFunction {
 	return c.decoder
@@ -1,12 +1,17 @@
 package csv
 
 import (
+	"bytes"
+	"errors"
 	"net"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	testifyassert "github.com/stretchr/testify/assert"
+	testifyrequire "github.com/stretchr/testify/require"
 )
 
 type MarshallableTime struct {
@@ -83,7 +88,7 @@ func Test_Cache(t *testing.T) {
 		Float64: 5151267.53235,
 		Bool:    true,
 		Time: MarshallableTime{
-			Time: time.Now().Truncate(time.Second),
+			Time: time.Now().UTC().Truncate(time.Second),
 		},
 		IP: net.IPv4(10, 10, 10, 10),
 	}
@@ -161,3 +166,130 @@ func Test_Cache(t *testing.T) {
 	}
 	assert.Equal(tmp1, tmp4)
 }
+
+type multiFormatID struct {
+	Prefix string
+	Number int
+}
+
+func (m multiFormatID) ShortString() string {
+	return strconv.Itoa(m.Number)
+}
+
+func (m *multiFormatID) LongString() (string, error) {
+	return m.Prefix + "-" + strconv.Itoa(m.Number), nil
+}
+
+func (m *multiFormatID) ParseLongString(data string) error {
+	prefix, number, ok := strings.Cut(data, "-")
+	if !ok {
+		return errors.New("missing prefix")
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return err
+	}
+	m.Prefix = prefix
+	m.Number = n
+	return nil
+}
+
+type methodStruct struct {
+	Short multiFormatID `csv:"short,method=ShortString"`
+	Long  multiFormatID `csv:"long,method=LongString,setmethod=ParseLongString"`
+	Age   int           `csv:"age,method=Missing,setmethod=Missing"`
+}
+
+func Test_MethodTag(t *testing.T) {
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[methodStruct](&buf)
+	err := writer.WriteRecord(methodStruct{
+		Short: multiFormatID{Prefix: "usr", Number: 12},
+		Long:  multiFormatID{Prefix: "org", Number: 7},
+		Age:   32,
+	})
+	require.NoError(err)
+	require.NoError(writer.Flush())
+	require.Equal("short,long,age\n12,org-7,32\n", buf.String())
+
+	reader := NewStructuredCSVReader[methodStruct](strings.NewReader("long,age\norg-7,32\nbad,1\n"))
+	record, err := reader.Next()
+	require.NoError(err)
+	require.Equal(multiFormatID{Prefix: "org", Number: 7}, record.Long)
+	require.Equal(32, record.Age)
+	_, err = reader.Next()
+	require.EqualError(err, "on row 3: column \"long\": missing prefix")
+}
+
+// stringerOnly implements Stringer and none of the marshal interfaces
+type stringerOnly struct {
+	Major, Minor int
+}
+
+func (s stringerOnly) String() string {
+	return strconv.Itoa(s.Major) + "." + strconv.Itoa(s.Minor)
+}
+
+func Test_StringerEncoder(t *testing.T) {
+	type stringerStruct struct {
+		Version  stringerOnly `csv:"version"`
+		Optional stringerOnly `csv:"optional,omitempty"`
+	}
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[stringerStruct](&buf)
+	require.NoError(writer.WriteRecord(
+		stringerStruct{Version: stringerOnly{Major: 1, Minor: 2}},
+		stringerStruct{Version: stringerOnly{}, Optional: stringerOnly{Major: 3}},
+	))
+	require.NoError(writer.Flush())
+	require.Equal("version,optional\n1.2,\n0.0,3.0\n", buf.String())
+}
+
+// fromStringVersion pairs Stringer with FromStringer to round trip without the CSV or text interfaces
+type fromStringVersion struct {
+	Major, Minor int
+}
+
+func (v fromStringVersion) String() string {
+	return strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor)
+}
+
+func (v *fromStringVersion) FromString(data string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	major, minor, ok := strings.Cut(data, ".")
+	if !ok {
+		return errors.New("missing minor version")
+	}
+	var err error
+	if v.Major, err = strconv.Atoi(major); err != nil {
+		return err
+	}
+	v.Minor, err = strconv.Atoi(minor)
+	return err
+}
+
+func Test_FromStringer(t *testing.T) {
+	type versionStruct struct {
+		Version fromStringVersion `csv:"version,required"`
+	}
+	require := testifyrequire.New(t)
+	records := []versionStruct{{Version: fromStringVersion{Major: 1, Minor: 2}}, {Version: fromStringVersion{Major: 10}}}
+	buf := bytes.Buffer{}
+	writer := NewWriter[versionStruct](&buf)
+	require.NoError(writer.WriteRecord(records...))
+	require.NoError(writer.Flush())
+	require.Equal("version\n1.2\n10.0\n", buf.String())
+
+	reader := NewStructuredCSVReader[versionStruct](&buf)
+	out, err := readAllRecords(reader)
+	require.NoError(err)
+	require.Equal(records, out)
+
+	reader = NewStructuredCSVReader[versionStruct](strings.NewReader("version\n3\n"))
+	_, err = reader.Next()
+	require.EqualError(err, `on row 2: column "version": missing minor version`)
+}
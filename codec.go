@@ -0,0 +1,97 @@
+package csv
+
+import (
+	"reflect"
+	"sync"
+)
+
+// registeredCodec is a codec registered with RegisterCodec with its type erased.
+type registeredCodec struct {
+	encode func(reflect.Value) (string, error)
+	decode func(string) (reflect.Value, error)
+}
+
+// codecRegistry holds the codecs registered with RegisterCodec for each type.
+var codecRegistry = struct {
+	mu     sync.RWMutex
+	codecs map[reflect.Type]registeredCodec
+}{codecs: map[reflect.Type]registeredCodec{}}
+
+// RegisterCodec sets how fields of type T (or *T) are encoded and decoded, taking the place of the built-in handling and the marshal interfaces.
+// This is useful for types from other packages such as uuid.UUID or decimal.Decimal.
+// Tag options that pick a codec of their own (e.g. method= or format=) still take precedence.
+// Registering the same type again replaces its codec, types can be registered at any time including after they've been used.
+func RegisterCodec[T any](enc func(T) (string, error), dec func(string) (T, error)) {
+	codecRegistry.mu.Lock()
+	defer codecRegistry.mu.Unlock()
+	codecRegistry.codecs[reflect.TypeFor[T]()] = registeredCodec{
+		encode: func(val reflect.Value) (string, error) {
+			return enc(val.Interface().(T))
+		},
+		decode: func(s string) (reflect.Value, error) {
+			val, err := dec(s)
+			return reflect.ValueOf(&val).Elem(), err
+		},
+	}
+}
+
+// getRegisteredCodec gets the codec registered for a type or the type it points to.
+func getRegisteredCodec(fieldType reflect.Type) (registeredCodec, bool) {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	codecRegistry.mu.RLock()
+	defer codecRegistry.mu.RUnlock()
+	codec, ok := codecRegistry.codecs[fieldType]
+	return codec, ok
+}
+
+// getRegisteredEncoder wraps an encoder to use the codec registered for the type, if there is one.
+// The registry is checked on every call since encoders are cached with the type's instructions.
+func getRegisteredEncoder(fieldType reflect.Type, omitEmpty bool, fallback encoderFunction) encoderFunction {
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		codec, ok := getRegisteredCodec(fieldType)
+		if !ok {
+			return fallback(val)
+		}
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		if val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return "", nil
+			}
+			val = val.Elem()
+		}
+		return codec.encode(val)
+	}
+}
+
+// getRegisteredDecoder wraps a decoder to use the codec registered for the type, if there is one.
+// A null cell yields the zero value (or a nil pointer) without calling the codec.
+func getRegisteredDecoder(fieldType reflect.Type, fieldName string, required bool, fallback decoderFunction) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	return func(s string, isNull bool) (any, error) {
+		codec, ok := getRegisteredCodec(fieldType)
+		if !ok {
+			return fallback(s, isNull)
+		}
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		if isNull {
+			return reflect.Zero(fieldType).Interface(), nil
+		}
+		val, err := codec.decode(s)
+		if err != nil {
+			return nil, err
+		}
+		if fieldType.Kind() == reflect.Ptr {
+			ptr := reflect.New(fieldType.Elem())
+			ptr.Elem().Set(val)
+			return ptr.Interface(), nil
+		}
+		return val.Interface(), nil
+	}
+}
@@ -0,0 +1,81 @@
+package csv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+// testCents is a struct with no marshal interfaces, it can only be encoded through a registered codec
+type testCents struct {
+	Cents int64
+}
+
+type codecRecord struct {
+	Price    testCents  `csv:"price,required"`
+	Discount *testCents `csv:"discount"`
+}
+
+func init() {
+	RegisterCodec(func(c testCents) (string, error) {
+		return fmt.Sprintf("%d.%02d", c.Cents/100, c.Cents%100), nil
+	}, func(s string) (testCents, error) {
+		var dollars, cents int64
+		if _, err := fmt.Sscanf(s, "%d.%02d", &dollars, &cents); err != nil {
+			return testCents{}, fmt.Errorf("%q is not a price: %w", s, err)
+		}
+		return testCents{Cents: dollars*100 + cents}, nil
+	})
+}
+
+func TestRegisterCodec(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		records := []codecRecord{
+			{Price: testCents{Cents: 1234}, Discount: &testCents{Cents: 5}},
+			{Price: testCents{Cents: 100}},
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[codecRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("price,discount\n12.34,0.05\n1.00,\n", buf.String())
+
+		reader := NewStructuredCSVReader[codecRecord](&buf)
+		out, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal(records, out)
+	})
+	t.Run("decode errors", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[codecRecord](strings.NewReader("price,discount\nfree,\n,0.05\n"))
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "price": "free" is not a price: expected integer`)
+		_, err = reader.Next()
+		require.EqualError(err, `on row 3: column "price": price is a required field`)
+	})
+	t.Run("registered after first use", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		type lateType struct{ V string }
+		type lateRecord struct {
+			Late lateType `csv:"late"`
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[lateRecord](&buf)
+		require.EqualError(writer.WriteRecord(lateRecord{}), "can not serialize type struct")
+
+		RegisterCodec(func(l lateType) (string, error) {
+			return l.V, nil
+		}, func(s string) (lateType, error) {
+			return lateType{V: s}, nil
+		})
+		buf.Reset()
+		writer = NewWriter[lateRecord](&buf)
+		require.NoError(writer.WriteRecord(lateRecord{Late: lateType{V: "ok"}}))
+		require.NoError(writer.Flush())
+		require.Equal("late\nok\n", buf.String())
+	})
+}
@@ -0,0 +1,108 @@
+package csv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// errTooManyColumns is returned when a row has more columns than MaxColumns allows.
+var errTooManyColumns = errors.New("too many columns")
+
+// columnLimitReader enforces MaxColumns by counting the delimiters of each line as it streams past,
+// so a line with a huge number of columns fails before csv.Reader parses and allocates it.
+// Quoting is tracked so delimiters and newlines inside of a quoted field are not counted.
+// The rest of a line that goes over the limit is dropped, so the following rows can still be read.
+//
+// Limitations:
+//   - Only the standard double quote is treated as a quote character.
+type columnLimitReader struct {
+	src        *bufio.Reader
+	delimiter  rune
+	maxColumns int
+	// columns is the number of columns seen so far on the current line
+	columns int
+	// inQuotes tracks if the current position is inside a quoted field
+	inQuotes bool
+	// skipping is set while dropping the rest of a line that went over the limit
+	skipping bool
+	// err is returned by the next read once the data before it has been handed back
+	err error
+	// pending holds encoded bytes that did not fit into the last buffer
+	pending []byte
+}
+
+func newColumnLimitReader(src io.Reader, delimiter rune, maxColumns int) *columnLimitReader {
+	return &columnLimitReader{
+		src:        bufio.NewReader(src),
+		delimiter:  delimiter,
+		maxColumns: maxColumns,
+		columns:    1,
+	}
+}
+
+// Read implements io.Reader
+func (c *columnLimitReader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		if len(c.pending) > 0 {
+			copied := copy(p[n:], c.pending)
+			c.pending = c.pending[copied:]
+			n += copied
+			continue
+		}
+		if c.err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			err := c.err
+			c.err = nil
+			return 0, err
+		}
+		if n > 0 && c.src.Buffered() == 0 {
+			// Don't block on the source if there is already data to hand back
+			break
+		}
+		r, size, err := c.src.ReadRune()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if r == utf8.RuneError && size == 1 {
+			// Pass invalid bytes through as-is
+			_ = c.src.UnreadRune()
+			b, _ := c.src.ReadByte()
+			if !c.skipping {
+				p[n] = b
+				n++
+			}
+			continue
+		}
+		switch {
+		case r == '"':
+			c.inQuotes = !c.inQuotes
+		case c.inQuotes:
+		case r == '\n':
+			c.columns = 1
+			if c.skipping {
+				// The newline still ends the row that failed
+				c.skipping = false
+			}
+		case r == c.delimiter && !c.skipping:
+			c.columns++
+			if c.columns > c.maxColumns {
+				c.skipping = true
+				c.err = fmt.Errorf("%w, the maximum is %v", errTooManyColumns, c.maxColumns)
+			}
+		}
+		if c.skipping && r != '\n' {
+			continue
+		}
+		c.pending = utf8.AppendRune(c.pending[:0], r)
+	}
+	return n, nil
+}
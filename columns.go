@@ -0,0 +1,45 @@
+package csv
+
+import (
+	"reflect"
+)
+
+// ColumnInfo describes a column as derived from a record's struct tags.
+type ColumnInfo struct {
+	// Name is the CSV header name for the column
+	Name string
+	// Field is the name of the struct field the column maps to
+	Field string
+	// Type is the type of the struct field
+	Type reflect.Type
+	// Required is set if the field was tagged as required
+	Required bool
+	// OmitEmpty is set if the field was tagged with omitempty
+	OmitEmpty bool
+	// Aliases are the alternate header names from the alias= tag options
+	Aliases []string
+}
+
+// DescribeColumns returns the columns for a record type in the order they are written.
+func DescribeColumns[Record any]() []ColumnInfo {
+	var rec Record
+	tOf := reflect.TypeOf(rec)
+	instructions := fieldCache.GetTypeDataFor(tOf)
+	if tOf.Kind() == reflect.Ptr {
+		tOf = tOf.Elem()
+	}
+	var out []ColumnInfo
+	for _, field := range columnFields(instructions) {
+		instruction := field.InstructionData()
+		structField := tOf.FieldByIndex(field.index)
+		out = append(out, ColumnInfo{
+			Name:      instruction.GetCSVHeaderIdentifier(),
+			Field:     structField.Name,
+			Type:      structField.Type,
+			Required:  instruction.IsRequired(),
+			OmitEmpty: instruction.IsOmitEmpty(),
+			Aliases:   instruction.GetAliases(),
+		})
+	}
+	return out
+}
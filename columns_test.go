@@ -0,0 +1,24 @@
+package csv
+
+import (
+	"reflect"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type describeRecord struct {
+	ID      int     `csv:"id,required"`
+	Name    string  `csv:"name,omitempty"`
+	Balance float64 `csv:"balance,required,omitempty"`
+	Ignored string  `csv:"-"`
+}
+
+func TestDescribeColumns(t *testing.T) {
+	require := testifyrequire.New(t)
+	require.Equal([]ColumnInfo{
+		{Name: "id", Field: "ID", Type: reflect.TypeFor[int](), Required: true},
+		{Name: "name", Field: "Name", Type: reflect.TypeFor[string](), OmitEmpty: true},
+		{Name: "balance", Field: "Balance", Type: reflect.TypeFor[float64](), Required: true, OmitEmpty: true},
+	}, DescribeColumns[describeRecord]())
+}
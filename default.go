@@ -0,0 +1,21 @@
+package csv
+
+import "fmt"
+
+// getDefaultDecoder wraps a decoder to decode the default= tag value in place of a null cell.
+// The default is checked when the field is set up, an invalid default results in a decoder that always errors.
+// Required fields still error on a null cell.
+func getDefaultDecoder(decoder decoderFunction, fieldName string, defaultValue string, required bool) decoderFunction {
+	if _, err := decoder(defaultValue, len(defaultValue) == 0); err != nil {
+		return func(s string, isNull bool) (any, error) {
+			return nil, fmt.Errorf("%v has an invalid default %q: %w", fieldName, defaultValue, err)
+		}
+	}
+	return func(s string, isNull bool) (any, error) {
+		if isNull && !required {
+			// Decode the default every time so values such as maps are not shared between records
+			return decoder(defaultValue, len(defaultValue) == 0)
+		}
+		return decoder(s, isNull)
+	}
+}
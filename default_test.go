@@ -0,0 +1,50 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type defaultRecord struct {
+	Name    string  `csv:"name,default=anonymous"`
+	Age     int     `csv:"age,default=18"`
+	Balance float64 `csv:"balance,default=1.5,required"`
+}
+
+type invalidDefaultRecord struct {
+	Age int `csv:"age,default=eighteen"`
+}
+
+func TestDefault(t *testing.T) {
+	t.Run("empty cells", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[defaultRecord](strings.NewReader("name,age,balance\n,,2\nalice,30,3\n"))
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]defaultRecord{
+			{Name: "anonymous", Age: 18, Balance: 2},
+			{Name: "alice", Age: 30, Balance: 3},
+		}, records)
+	})
+	t.Run("missing column", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[defaultRecord](strings.NewReader("name,balance\nalice,2\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(defaultRecord{Name: "alice", Age: 18, Balance: 2}, record)
+	})
+	t.Run("required wins", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[defaultRecord](strings.NewReader("name,balance\nalice,\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"balance\": balance is a required field")
+	})
+	t.Run("invalid default", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[invalidDefaultRecord](strings.NewReader("age\n5\n"))
+		_, err := reader.Next()
+		require.ErrorContains(err, `on row 2: column "age": age has an invalid default "eighteen": strconv.ParseInt`)
+	})
+}
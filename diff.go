@@ -0,0 +1,117 @@
+package csv
+
+import (
+	"io"
+
+	"github.com/weisbartb/stack"
+)
+
+// DiffKind describes how a record differs between two CSVs.
+type DiffKind int
+
+const (
+	// DiffAdded is a record that is only in the second CSV
+	DiffAdded DiffKind = iota + 1
+	// DiffRemoved is a record that is only in the first CSV
+	DiffRemoved
+	// DiffChanged is a record in both CSVs that is not equal
+	DiffChanged
+)
+
+// String formats the kind for logging.
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// Diff is a single difference between two CSVs of the same record type.
+type Diff[Record any] struct {
+	Kind DiffKind
+	// Index is the zero-based position of the record in the first CSV, or in the second CSV for added records
+	Index int
+	// A is the record from the first CSV, this is the zero value for added records
+	A Record
+	// B is the record from the second CSV, this is the zero value for removed records
+	B Record
+}
+
+// DiffCSV compares two CSVs record by record in order.
+// Extra records at the end of b are added and extra records at the end of a are removed.
+func DiffCSV[Record comparable](a, b io.Reader) ([]Diff[Record], error) {
+	return DiffCSVFunc(a, b, func(x, y Record) bool {
+		return x == y
+	})
+}
+
+// DiffCSVFunc compares two CSVs record by record in order using equal, this supports records that are not comparable.
+func DiffCSVFunc[Record any](a, b io.Reader, equal func(x, y Record) bool) ([]Diff[Record], error) {
+	recordsA, recordsB, err := readDiffRecords[Record](a, b)
+	if err != nil {
+		return nil, stack.Trace(err)
+	}
+	var diffs []Diff[Record]
+	for idx := 0; idx < max(len(recordsA), len(recordsB)); idx++ {
+		switch {
+		case idx >= len(recordsA):
+			diffs = append(diffs, Diff[Record]{Kind: DiffAdded, Index: idx, B: recordsB[idx]})
+		case idx >= len(recordsB):
+			diffs = append(diffs, Diff[Record]{Kind: DiffRemoved, Index: idx, A: recordsA[idx]})
+		case !equal(recordsA[idx], recordsB[idx]):
+			diffs = append(diffs, Diff[Record]{Kind: DiffChanged, Index: idx, A: recordsA[idx], B: recordsB[idx]})
+		}
+	}
+	return diffs, nil
+}
+
+// DiffCSVByKey compares two CSVs matching records by the value of key rather than their position, so reordering is not a difference.
+// Removed and changed records are reported in the order of a followed by added records in the order of b.
+// If a key repeats within a CSV the last record with that key is used.
+func DiffCSVByKey[Record any, K comparable](a, b io.Reader, key func(Record) K, equal func(x, y Record) bool) ([]Diff[Record], error) {
+	recordsA, recordsB, err := readDiffRecords[Record](a, b)
+	if err != nil {
+		return nil, stack.Trace(err)
+	}
+	keysA := make(map[K]struct{}, len(recordsA))
+	for _, record := range recordsA {
+		keysA[key(record)] = struct{}{}
+	}
+	indexB := make(map[K]int, len(recordsB))
+	for idx, record := range recordsB {
+		indexB[key(record)] = idx
+	}
+	var diffs []Diff[Record]
+	for idx, record := range recordsA {
+		other, ok := indexB[key(record)]
+		if !ok {
+			diffs = append(diffs, Diff[Record]{Kind: DiffRemoved, Index: idx, A: record})
+		} else if !equal(record, recordsB[other]) {
+			diffs = append(diffs, Diff[Record]{Kind: DiffChanged, Index: idx, A: record, B: recordsB[other]})
+		}
+	}
+	for idx, record := range recordsB {
+		if _, ok := keysA[key(record)]; !ok {
+			diffs = append(diffs, Diff[Record]{Kind: DiffAdded, Index: idx, B: record})
+		}
+	}
+	return diffs, nil
+}
+
+// readDiffRecords decodes every record of both CSVs.
+func readDiffRecords[Record any](a, b io.Reader) ([]Record, []Record, error) {
+	recordsA, err := NewStructuredCSVReader[Record](a).ReadAll()
+	if err != nil {
+		return nil, nil, stack.Wrap(err, "reading first csv")
+	}
+	recordsB, err := NewStructuredCSVReader[Record](b).ReadAll()
+	if err != nil {
+		return nil, nil, stack.Wrap(err, "reading second csv")
+	}
+	return recordsA, recordsB, nil
+}
@@ -0,0 +1,55 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type diffRecord struct {
+	ID    int    `csv:"id"`
+	Name  string `csv:"name"`
+	Email string `csv:"email"`
+}
+
+func TestDiffCSV(t *testing.T) {
+	t.Run("by position", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		diffs, err := DiffCSV[diffRecord](
+			strings.NewReader("id,name\n1,a\n2,b\n3,c\n"),
+			strings.NewReader("id,name\n1,a\n2,x\n"),
+		)
+		require.NoError(err)
+		require.Equal([]Diff[diffRecord]{
+			{Kind: DiffChanged, Index: 1, A: diffRecord{ID: 2, Name: "b"}, B: diffRecord{ID: 2, Name: "x"}},
+			{Kind: DiffRemoved, Index: 2, A: diffRecord{ID: 3, Name: "c"}},
+		}, diffs)
+	})
+	t.Run("identical", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		diffs, err := DiffCSV[diffRecord](strings.NewReader("id,name\n1,a\n"), strings.NewReader("name,id\na,1\n"))
+		require.NoError(err)
+		require.Empty(diffs)
+	})
+	t.Run("by key", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		diffs, err := DiffCSVByKey(
+			strings.NewReader("id,name,email\n1,a,a@example.com\n2,b,b@example.com\n3,c,c@example.com\n"),
+			strings.NewReader("id,name,email\n4,d,d@example.com\n3,c,c@example.com\n1,a,A@example.com\n"),
+			func(r diffRecord) int { return r.ID },
+			func(x, y diffRecord) bool { return strings.EqualFold(x.Email, y.Email) && x.Name == y.Name },
+		)
+		require.NoError(err)
+		require.Equal([]Diff[diffRecord]{
+			{Kind: DiffRemoved, Index: 1, A: diffRecord{ID: 2, Name: "b", Email: "b@example.com"}},
+			{Kind: DiffAdded, Index: 0, B: diffRecord{ID: 4, Name: "d", Email: "d@example.com"}},
+		}, diffs)
+	})
+	t.Run("decode error", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		_, err := DiffCSV[diffRecord](strings.NewReader("id\n1\n"), strings.NewReader("id\nb\n"))
+		require.ErrorContains(err, "reading second csv")
+		require.ErrorContains(err, "on row 2")
+	})
+}
@@ -0,0 +1,188 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var tOfDuration = reflect.TypeFor[time.Duration]()
+
+// durationFormatISO8601 is the duration= tag option value for ISO 8601 durations.
+const durationFormatISO8601 = "iso8601"
+
+var errInvalidISO8601Duration = errors.New("invalid ISO 8601 duration")
+
+// parseISO8601Duration parses an ISO 8601 duration such as P1DT2H30M into a time.Duration.
+// Years and months do not have a fixed length, so they are rejected rather than approximated.
+// Weeks are treated as 7 days and days as 24 hours; fractional values are supported on any unit.
+func parseISO8601Duration(s string) (time.Duration, error) {
+	var neg bool
+	orig := s
+	if strings.HasPrefix(s, "-") {
+		neg = true
+		s = s[1:]
+	}
+	if len(s) < 2 || s[0] != 'P' {
+		return 0, fmt.Errorf("%w %q", errInvalidISO8601Duration, orig)
+	}
+	s = s[1:]
+	var out time.Duration
+	var inTime bool
+	// lastUnit is the largest unit seen so far, each unit can be used once and they must go from largest to smallest
+	var lastUnit time.Duration
+	for len(s) > 0 {
+		if s[0] == 'T' {
+			if inTime || len(s) == 1 {
+				return 0, fmt.Errorf("%w %q", errInvalidISO8601Duration, orig)
+			}
+			inTime = true
+			s = s[1:]
+			continue
+		}
+		// Find the end of the number
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.' && r != ','
+		})
+		if end <= 0 {
+			return 0, fmt.Errorf("%w %q", errInvalidISO8601Duration, orig)
+		}
+		number := strings.Replace(s[:end], ",", ".", 1)
+		var unit time.Duration
+		switch designator := s[end]; {
+		case !inTime && (designator == 'Y' || designator == 'M'):
+			return 0, fmt.Errorf("ISO 8601 duration %q uses years or months which have no fixed length", orig)
+		case !inTime && designator == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && designator == 'D':
+			unit = 24 * time.Hour
+		case inTime && designator == 'H':
+			unit = time.Hour
+		case inTime && designator == 'M':
+			unit = time.Minute
+		case inTime && designator == 'S':
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("%w %q", errInvalidISO8601Duration, orig)
+		}
+		if lastUnit > 0 && unit >= lastUnit {
+			return 0, fmt.Errorf("%w %q: units must be in order and not repeated", errInvalidISO8601Duration, orig)
+		}
+		lastUnit = unit
+		val, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", errInvalidISO8601Duration, orig)
+		}
+		whole, frac, _ := strings.Cut(number, ".")
+		wholeVal, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", errInvalidISO8601Duration, orig)
+		}
+		// Keep whole units exact and only use floating point for the fractional remainder
+		out += time.Duration(wholeVal) * unit
+		if len(frac) > 0 {
+			out += time.Duration((val - float64(wholeVal)) * float64(unit))
+		}
+		s = s[end+1:]
+	}
+	if neg {
+		out = -out
+	}
+	return out, nil
+}
+
+// formatISO8601Duration formats a time.Duration as an ISO 8601 duration using days, hours, minutes, and seconds.
+func formatISO8601Duration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	var out strings.Builder
+	if d < 0 {
+		out.WriteByte('-')
+		d = -d
+	}
+	out.WriteByte('P')
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	if days > 0 {
+		out.WriteString(strconv.FormatInt(int64(days), 10))
+		out.WriteByte('D')
+	}
+	if d == 0 {
+		return out.String()
+	}
+	out.WriteByte('T')
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	if hours > 0 {
+		out.WriteString(strconv.FormatInt(int64(hours), 10))
+		out.WriteByte('H')
+	}
+	if minutes > 0 {
+		out.WriteString(strconv.FormatInt(int64(minutes), 10))
+		out.WriteByte('M')
+	}
+	if d > 0 {
+		out.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+		out.WriteByte('S')
+	}
+	return out.String()
+}
+
+// getDurationEncoder returns an encoder for a time.Duration field using the duration= tag format.
+func getDurationEncoder(fieldType reflect.Type, format string, omitEmpty bool) encoderFunction {
+	if format != durationFormatISO8601 {
+		return func(val reflect.Value) (string, error) {
+			return "", fmt.Errorf("unknown duration format %q", format)
+		}
+	}
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		if val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return "", nil
+			}
+			val = val.Elem()
+		}
+		return formatISO8601Duration(time.Duration(val.Int())), nil
+	}
+}
+
+// getDurationDecoder returns a decoder for a time.Duration or *time.Duration field using the duration= tag format.
+// An empty cell yields 0, or a nil pointer for a *time.Duration field.
+func getDurationDecoder(fieldType reflect.Type, fieldName string, format string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	if format != durationFormatISO8601 {
+		return func(s string, isNull bool) (any, error) {
+			return nil, fmt.Errorf("%v has an unknown duration format %q", fieldName, format)
+		}
+	}
+	isPtr := fieldType.Kind() == reflect.Ptr
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		if len(s) == 0 {
+			if isPtr {
+				return (*time.Duration)(nil), nil
+			}
+			return time.Duration(0), nil
+		}
+		d, err := parseISO8601Duration(s)
+		if err != nil {
+			return nil, err
+		}
+		if isPtr {
+			return &d, nil
+		}
+		return d, nil
+	}
+}
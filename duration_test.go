@@ -0,0 +1,92 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type durationRecord struct {
+	Elapsed time.Duration `csv:"elapsed,duration=iso8601"`
+}
+
+func TestParseISO8601Duration(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected time.Duration
+		err      string
+	}{
+		{in: "PT1H30M", expected: 90 * time.Minute},
+		{in: "P1D", expected: 24 * time.Hour},
+		{in: "P1DT2H", expected: 26 * time.Hour},
+		{in: "P1W", expected: 7 * 24 * time.Hour},
+		{in: "PT1.5S", expected: 1500 * time.Millisecond},
+		{in: "-PT10M", expected: -10 * time.Minute},
+		{in: "1H30M", err: `invalid ISO 8601 duration "1H30M"`},
+		{in: "PT", err: `invalid ISO 8601 duration "PT"`},
+		{in: "P1H", err: `invalid ISO 8601 duration "P1H"`},
+		{in: "P1M", err: `ISO 8601 duration "P1M" uses years or months which have no fixed length`},
+		{in: "P1Y", err: `ISO 8601 duration "P1Y" uses years or months which have no fixed length`},
+		{in: "PT1S1H", err: `invalid ISO 8601 duration "PT1S1H": units must be in order and not repeated`},
+		{in: "PT1H1H", err: `invalid ISO 8601 duration "PT1H1H": units must be in order and not repeated`},
+		{in: "P1D1W", err: `invalid ISO 8601 duration "P1D1W": units must be in order and not repeated`},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			require := testifyrequire.New(t)
+			d, err := parseISO8601Duration(tc.in)
+			if len(tc.err) > 0 {
+				require.EqualError(err, tc.err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tc.expected, d)
+		})
+	}
+}
+
+func TestDurationFields(t *testing.T) {
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[durationRecord](&buf)
+	err := writer.WriteRecord(
+		durationRecord{Elapsed: 90 * time.Minute},
+		durationRecord{Elapsed: 26*time.Hour + 1500*time.Millisecond},
+		durationRecord{},
+	)
+	require.NoError(err)
+	require.NoError(writer.Flush())
+	require.Equal("elapsed\nPT1H30M\nP1DT2H1.5S\nPT0S\n", buf.String())
+
+	reader := NewStructuredCSVReader[durationRecord](strings.NewReader(buf.String()))
+	for _, expected := range []time.Duration{90 * time.Minute, 26*time.Hour + 1500*time.Millisecond, 0} {
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(expected, record.Elapsed)
+	}
+
+	reader = NewStructuredCSVReader[durationRecord](strings.NewReader("elapsed\n1h30m\n"))
+	_, err = reader.Next()
+	require.EqualError(err, `on row 2: column "elapsed": invalid ISO 8601 duration "1h30m"`)
+}
+
+func TestDurationPointerFields(t *testing.T) {
+	type pointerDurationRecord struct {
+		Name    string         `csv:"name"`
+		Elapsed *time.Duration `csv:"elapsed,duration=iso8601"`
+	}
+	require := testifyrequire.New(t)
+	elapsed := 90 * time.Minute
+	buf := bytes.Buffer{}
+	writer := NewWriter[pointerDurationRecord](&buf)
+	require.NoError(writer.WriteRecord(pointerDurationRecord{Name: "a", Elapsed: &elapsed}, pointerDurationRecord{Name: "b"}))
+	require.NoError(writer.Flush())
+	require.Equal("name,elapsed\na,PT1H30M\nb,\n", buf.String())
+
+	reader := NewStructuredCSVReader[pointerDurationRecord](&buf)
+	records, err := reader.ReadAll()
+	require.NoError(err)
+	require.Equal([]pointerDurationRecord{{Name: "a", Elapsed: &elapsed}, {Name: "b"}}, records)
+}
@@ -0,0 +1,79 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type embeddedCommon struct {
+	ID   int    `csv:"id,required"`
+	Kind string `csv:"kind"`
+}
+
+// EmbeddedAudit is exported so it can be embedded through a pointer
+type EmbeddedAudit struct {
+	CreatedBy string `csv:"created_by"`
+}
+
+type embeddedRecord struct {
+	embeddedCommon
+	*EmbeddedAudit
+	Extra string `csv:"extra"`
+}
+
+type collidingEmbeddedRecord struct {
+	embeddedCommon
+	Kind string `csv:"kind"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		records := []embeddedRecord{
+			{embeddedCommon: embeddedCommon{ID: 1, Kind: "a"}, EmbeddedAudit: &EmbeddedAudit{CreatedBy: "alice"}, Extra: "x"},
+			{embeddedCommon: embeddedCommon{ID: 2, Kind: "b"}, Extra: "y"},
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[embeddedRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		// A nil embedded pointer writes blank cells
+		require.Equal("id,kind,created_by,extra\n1,a,alice,x\n2,b,,y\n", buf.String())
+
+		reader := NewStructuredCSVReader[embeddedRecord](&buf)
+		out, err := readAllRecords(reader)
+		require.NoError(err)
+		// The embedded pointer is allocated since its column was present
+		records[1].EmbeddedAudit = &EmbeddedAudit{}
+		require.Equal(records, out)
+	})
+	t.Run("promoted required field", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[embeddedRecord](strings.NewReader("kind,extra\na,x\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "id is a required column but was not seen in the csv")
+	})
+	t.Run("describe columns", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		var names, fields []string
+		for _, column := range DescribeColumns[embeddedRecord]() {
+			names = append(names, column.Name)
+			fields = append(fields, column.Field)
+		}
+		require.Equal([]string{"id", "kind", "created_by", "extra"}, names)
+		require.Equal([]string{"ID", "Kind", "CreatedBy", "Extra"}, fields)
+	})
+	t.Run("header collision", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		const msg = "kind is the header of more than one field, including fields promoted from embedded structs"
+		reader := NewStructuredCSVReader[collidingEmbeddedRecord](strings.NewReader("id,kind\n1,a\n"))
+		_, err := reader.Next()
+		require.EqualError(err, msg)
+
+		writer := NewWriter[collidingEmbeddedRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(collidingEmbeddedRecord{}), msg)
+	})
+}
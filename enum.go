@@ -0,0 +1,77 @@
+package csv
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+)
+
+// enumRegistry holds the allowed values for string enum types registered with RegisterEnum.
+var enumRegistry = struct {
+	mu     sync.RWMutex
+	values map[reflect.Type]map[string]struct{}
+}{values: map[reflect.Type]map[string]struct{}{}}
+
+// RegisterEnum records the valid values for a named string type, e.g. type Color string.
+// Decoding a field of that type errors if the cell is not one of the valid values; null cells are not checked.
+// Registering the same type again replaces its valid values.
+func RegisterEnum[T ~string](valid ...T) {
+	values := make(map[string]struct{}, len(valid))
+	for _, v := range valid {
+		values[string(v)] = struct{}{}
+	}
+	enumRegistry.mu.Lock()
+	defer enumRegistry.mu.Unlock()
+	enumRegistry.values[reflect.TypeFor[T]()] = values
+}
+
+// checkEnum errors if the type is a registered enum and the value isn't one of its valid values.
+func checkEnum(t reflect.Type, val reflect.Value) error {
+	if t.Kind() != reflect.String {
+		return nil
+	}
+	enumRegistry.mu.RLock()
+	values, ok := enumRegistry.values[t]
+	enumRegistry.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	if _, ok := values[val.String()]; !ok {
+		return fmt.Errorf("%q is not a valid %v", val.String(), t)
+	}
+	return nil
+}
+
+// integer is satisfied by the types that can be registered with RegisterEnumLabels.
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// RegisterEnumLabels sets the labels an integer enum type is written and read as, e.g. type Status int with StatusActive written as active.
+// This registers a codec for the type (see RegisterCodec), so types without labels keep the numeric encoding.
+// Encoding a value without a label or decoding an unknown label errors; labels should be unique, if not the smallest value is decoded.
+func RegisterEnumLabels[T integer](labels map[T]string) {
+	// The labels are copied so later changes to the caller's map don't race with encoding
+	labels = maps.Clone(labels)
+	values := make(map[string]T, len(labels))
+	for val, label := range labels {
+		if existing, ok := values[label]; ok && existing < val {
+			continue
+		}
+		values[label] = val
+	}
+	RegisterCodec(func(val T) (string, error) {
+		label, ok := labels[val]
+		if !ok {
+			return "", fmt.Errorf("%d has no label for %v", val, reflect.TypeFor[T]())
+		}
+		return label, nil
+	}, func(s string) (T, error) {
+		val, ok := values[s]
+		if !ok {
+			return val, fmt.Errorf("%q is not a valid %v", s, reflect.TypeFor[T]())
+		}
+		return val, nil
+	})
+}
@@ -0,0 +1,89 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type testColor string
+
+const (
+	colorRed   testColor = "red"
+	colorGreen testColor = "green"
+)
+
+type enumRecord struct {
+	Name  string    `csv:"name"`
+	Color testColor `csv:"color"`
+}
+
+func TestRegisterEnum(t *testing.T) {
+	RegisterEnum(colorRed, colorGreen)
+	t.Run("valid", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[enumRecord](strings.NewReader("name,color\napple,red\nleaf,green\nnothing,\n"))
+		records, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal([]enumRecord{
+			{Name: "apple", Color: colorRed},
+			{Name: "leaf", Color: colorGreen},
+			{Name: "nothing"},
+		}, records)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[enumRecord](strings.NewReader("name,color\nsky,blue\n"))
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "color": "blue" is not a valid csv.testColor`)
+	})
+}
+
+type testStatus int
+
+const (
+	statusInactive testStatus = iota
+	statusActive
+	statusUnlabeled
+)
+
+type statusRecord struct {
+	Name   string      `csv:"name"`
+	Status testStatus  `csv:"status"`
+	Prior  *testStatus `csv:"prior"`
+}
+
+func TestRegisterEnumLabels(t *testing.T) {
+	RegisterEnumLabels(map[testStatus]string{statusInactive: "inactive", statusActive: "active"})
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		inactive := statusInactive
+		records := []statusRecord{
+			{Name: "a", Status: statusActive, Prior: &inactive},
+			{Name: "b", Status: statusInactive},
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[statusRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("name,status,prior\na,active,inactive\nb,inactive,\n", buf.String())
+
+		reader := NewStructuredCSVReader[statusRecord](&buf)
+		out, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal(records, out)
+	})
+	t.Run("unknown label", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[statusRecord](strings.NewReader("name,status\na,1\n"))
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "status": "1" is not a valid csv.testStatus`)
+	})
+	t.Run("value without a label", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[statusRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(statusRecord{Status: statusUnlabeled}), "2 has no label for csv.testStatus")
+	})
+}
@@ -0,0 +1,53 @@
+package csv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RequiredFieldError is returned when a field tagged as required has a null cell.
+type RequiredFieldError struct {
+	// Field is the header name of the field
+	Field string
+}
+
+func (e *RequiredFieldError) Error() string {
+	return fmt.Sprintf("%v is a required field", e.Field)
+}
+
+// UnsupportedTypeError is returned when a field's type has no way to be encoded or decoded.
+// Such types need to implement one of the marshal interfaces or be registered with RegisterCodec.
+type UnsupportedTypeError struct {
+	// Type is the type of the field
+	Type reflect.Type
+	// decoding is set when the type failed to decode rather than encode
+	decoding bool
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	if e.decoding {
+		return fmt.Sprintf("can not unserialize type %v", e.Type.Kind())
+	}
+	return fmt.Sprintf("can not serialize type %v", e.Type.Kind())
+}
+
+// DecodeError is returned by the Reader when a cell can't be decoded into its field, it wraps the cause.
+type DecodeError struct {
+	// Row is the row the cell was on
+	Row int
+	// Column is the header of the column the cell was in
+	Column string
+	// Field is the name of the struct field the cell was decoded into
+	Field string
+	// Err is the underlying error, such as a RequiredFieldError or a strconv.NumError
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("on row %v: column %q: %v", e.Row, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,57 @@
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+func TestTypedErrors(t *testing.T) {
+	t.Run("required field", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](strings.NewReader("a_string,a_float,an_int\nstring,1,\n"))
+		_, err := reader.Next()
+		var decodeErr *DecodeError
+		require.True(errors.As(err, &decodeErr))
+		require.Equal(2, decodeErr.Row)
+		require.Equal("an_int", decodeErr.Column)
+		require.Equal("AnInt", decodeErr.Field)
+		var requiredErr *RequiredFieldError
+		require.True(errors.As(err, &requiredErr))
+		require.Equal("an_int", requiredErr.Field)
+	})
+	t.Run("bad data", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\nstring,eleven\n"))
+		_, err := reader.Next()
+		var decodeErr *DecodeError
+		require.True(errors.As(err, &decodeErr))
+		require.Equal("an_int", decodeErr.Column)
+		var numErr *strconv.NumError
+		require.True(errors.As(err, &numErr))
+		var requiredErr *RequiredFieldError
+		require.False(errors.As(err, &requiredErr))
+	})
+	t.Run("unsupported type", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		type unsupportedRecord struct {
+			Values []int `csv:"values"`
+		}
+		writer := NewWriter[unsupportedRecord](&bytes.Buffer{})
+		err := writer.WriteRecord(unsupportedRecord{Values: []int{1}})
+		var unsupportedErr *UnsupportedTypeError
+		require.True(errors.As(err, &unsupportedErr))
+		require.Equal(reflect.TypeFor[[]int](), unsupportedErr.Type)
+		require.EqualError(err, "can not serialize type slice")
+
+		reader := NewStructuredCSVReader[unsupportedRecord](strings.NewReader("values\n1\n"))
+		_, err = reader.Next()
+		require.True(errors.As(err, &unsupportedErr))
+		require.EqualError(err, `on row 2: column "values": can not unserialize type slice`)
+	})
+}
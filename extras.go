@@ -0,0 +1,42 @@
+package csv
+
+import (
+	"reflect"
+	"slices"
+)
+
+// isExtrasMap checks if a field can be tagged with extras, it must be a map of strings to strings.
+// Named string types are allowed for both the keys and the values.
+func isExtrasMap(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Map && fieldType.Key().Kind() == reflect.String && fieldType.Elem().Kind() == reflect.String
+}
+
+// setExtra sets a column and its cell in the map of a field tagged with extras, making the map if it's nil.
+func setExtra(field reflect.Value, column, cell string) {
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	field.SetMapIndex(reflect.ValueOf(column).Convert(field.Type().Key()), reflect.ValueOf(cell).Convert(field.Type().Elem()))
+}
+
+// extraValue gets the cell for a column from the map of a field tagged with extras, ok is false if the map doesn't have it.
+func extraValue(field reflect.Value, column string) (val string, ok bool) {
+	if !field.IsValid() || field.Len() == 0 {
+		return "", false
+	}
+	cell := field.MapIndex(reflect.ValueOf(column).Convert(field.Type().Key()))
+	if !cell.IsValid() {
+		return "", false
+	}
+	return cell.String(), true
+}
+
+// extraKeys gets the sorted keys of the map of a field tagged with extras.
+func extraKeys(field reflect.Value) []string {
+	keys := make([]string, 0, field.Len())
+	for _, key := range field.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,95 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type extrasRecord struct {
+	Name  string            `csv:"name"`
+	Count int               `csv:"count"`
+	Extra map[string]string `csv:",extras"`
+}
+
+func TestExtras(t *testing.T) {
+	t.Run("read", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[extrasRecord](strings.NewReader("name,source,count,region\na,web,1,\nb,api,2,eu\n"))
+		reader.StrictMode = true
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]extrasRecord{
+			{Name: "a", Count: 1, Extra: map[string]string{"source": "web", "region": ""}},
+			{Name: "b", Count: 2, Extra: map[string]string{"source": "api", "region": "eu"}},
+		}, records)
+		require.Empty(reader.IgnoredColumns())
+	})
+	t.Run("no extra columns", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[extrasRecord](strings.NewReader("name,count\na,1\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Nil(record.Extra)
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		type badExtrasRecord struct {
+			Name  string         `csv:"name"`
+			Extra map[string]int `csv:",extras"`
+		}
+		reader := NewStructuredCSVReader[badExtrasRecord](strings.NewReader("name,other\na,1\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "Extra is tagged extras but is a map[string]int rather than a map[string]string")
+	})
+	t.Run("more than one", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		type twoExtrasRecord struct {
+			Name  string            `csv:"name"`
+			Extra map[string]string `csv:",extras"`
+			More  map[string]string `csv:",extras"`
+		}
+		reader := NewStructuredCSVReader[twoExtrasRecord](strings.NewReader("name,other\na,1\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "Extra and More are both tagged extras")
+	})
+}
+
+func TestWriter_Extras(t *testing.T) {
+	records := []extrasRecord{
+		{Name: "a", Count: 1, Extra: map[string]string{"source": "web"}},
+		{Name: "b", Count: 2, Extra: map[string]string{"source": "api", "region": "eu"}},
+		{Name: "c", Count: 3},
+	}
+	t.Run("union of keys", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[extrasRecord](&buf)
+		require.NoError(writer.WriteAll(records))
+		require.Equal("name,count,region,source\na,1,,web\nb,2,eu,api\nc,3,,\n", buf.String())
+
+		// The columns round trip through the reader
+		reader := NewStructuredCSVReader[extrasRecord](&buf)
+		out, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal(map[string]string{"region": "eu", "source": "api"}, out[1].Extra)
+	})
+	t.Run("column order", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriterWithColumnOrder[*extrasRecord](&buf, []string{"source", "name", "region"})
+		require.NoError(writer.WriteRecord(&records[0], nil, &records[1]))
+		require.NoError(writer.Flush())
+		require.Equal("source,name,region\nweb,a,\n,,\napi,b,eu\n", buf.String())
+	})
+	t.Run("key missing from the header", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[extrasRecord](&bytes.Buffer{})
+		require.NoError(writer.WriteRecord(records[0]))
+		require.NoError(writer.Flush())
+		err := writer.WriteRecord(records[1])
+		require.EqualError(err, `Extra has the extras key "region" which has no column, the extra columns are taken from the records in the first write`)
+	})
+}
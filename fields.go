@@ -0,0 +1,231 @@
+package csv
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/weisbartb/rcache"
+)
+
+// recordField is a field that maps to a column, fields promoted from embedded structs are included.
+type recordField struct {
+	*rcache.FieldCache[csvInstruction]
+	// index is the sequence of field indexes from the record to the field, as used by reflect.Value.FieldByIndex
+	index []int
+}
+
+// valueOf gets the field from a record for encoding, a nil embedded pointer yields the zero value of the field.
+func (f *recordField) valueOf(record reflect.Value) reflect.Value {
+	val := record
+	for pos, idx := range f.index {
+		if pos > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return reflect.Zero(record.Type().FieldByIndex(f.index).Type)
+			}
+			val = val.Elem()
+		}
+		val = val.Field(idx)
+	}
+	return val
+}
+
+// settableOf gets the field from a record for decoding, nil embedded pointers are allocated along the way.
+func (f *recordField) settableOf(record reflect.Value) reflect.Value {
+	val := record
+	for pos, idx := range f.index {
+		if pos > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				val.Set(reflect.New(val.Type().Elem()))
+			}
+			val = val.Elem()
+		}
+		val = val.Field(idx)
+	}
+	return val
+}
+
+// recordLayout holds the fields of a record type with the fields of embedded structs promoted into it.
+type recordLayout struct {
+	fields []*recordField
+	byName map[string]*recordField
+	// extras is the field tagged with extras, it is kept out of fields since it doesn't map to a single column
+	extras *recordField
+	// err is set if the fields can't be mapped to columns, such as two fields with the same header or index
+	err error
+}
+
+// recordLayouts caches the layout for each record type, keyed by the type's field cache.
+var recordLayouts sync.Map
+
+// getRecordLayout gets the layout for the fields of a record type.
+func getRecordLayout(instructions *rcache.FieldCache[csvInstruction]) *recordLayout {
+	if layout, ok := recordLayouts.Load(instructions); ok {
+		return layout.(*recordLayout)
+	}
+	layout := &recordLayout{byName: map[string]*recordField{}}
+	layout.add(instructions, nil)
+	if layout.err == nil {
+		layout.err = layout.order()
+	}
+	recordLayouts.Store(instructions, layout)
+	return layout
+}
+
+// add adds the fields of a struct to the layout, recursing into embedded structs.
+func (l *recordLayout) add(instructions *rcache.FieldCache[csvInstruction], index []int) {
+	for _, field := range instructions.Fields() {
+		fieldIndex := append(slices.Clone(index), field.Idx)
+		instruction := field.InstructionData()
+		if instruction.IsEmbedded() {
+			l.add(field, fieldIndex)
+			continue
+		}
+		if instruction.IsExtras() {
+			l.addExtras(&recordField{FieldCache: field, index: fieldIndex})
+			continue
+		}
+		name := instruction.lookupName()
+		if _, ok := l.byName[name]; ok && l.err == nil {
+			l.err = fmt.Errorf("%v is the header of more than one field, including fields promoted from embedded structs", name)
+		}
+		column := &recordField{FieldCache: field, index: fieldIndex}
+		l.fields = append(l.fields, column)
+		l.byName[name] = column
+	}
+}
+
+// addExtras sets the field tagged with extras, a record can only have one.
+func (l *recordLayout) addExtras(field *recordField) {
+	instruction := field.InstructionData()
+	switch {
+	case l.err != nil:
+	case instruction.extrasErr != nil:
+		l.err = instruction.extrasErr
+	case l.extras != nil:
+		l.err = fmt.Errorf("%v and %v are both tagged extras", l.extras.InstructionData().structFieldName, instruction.structFieldName)
+	}
+	l.extras = field
+}
+
+// order sorts the fields with an index= tag option ahead of the rest by their index, the other fields keep their declared order.
+// An invalid or duplicate index is an error.
+func (l *recordLayout) order() error {
+	indexes := make(map[*recordField]int, len(l.fields))
+	seen := map[int]string{}
+	for _, field := range l.fields {
+		instruction := field.InstructionData()
+		if len(instruction.columnIndex) == 0 {
+			continue
+		}
+		name := instruction.lookupName()
+		idx, err := strconv.Atoi(instruction.columnIndex)
+		if err != nil || idx < 0 {
+			return fmt.Errorf("%v has an invalid index= value %q", name, instruction.columnIndex)
+		}
+		if other, ok := seen[idx]; ok {
+			return fmt.Errorf("%v and %v both use index=%v", other, name, idx)
+		}
+		seen[idx] = name
+		indexes[field] = idx
+	}
+	slices.SortStableFunc(l.fields, func(a, b *recordField) int {
+		aIdx, aOk := indexes[a]
+		bIdx, bOk := indexes[b]
+		switch {
+		case aOk && bOk:
+			return aIdx - bIdx
+		case aOk:
+			return -1
+		case bOk:
+			return 1
+		}
+		return 0
+	})
+	return nil
+}
+
+// isPromotedStruct checks if a struct field is an embedded struct whose fields should be promoted into the record.
+// Embedded structs with a header name in their tag or that encode themselves are kept as a single field.
+func isPromotedStruct(field reflect.StructField, tag string) bool {
+	if !field.Anonymous || len(strings.SplitN(tag, ",", 2)[0]) > 0 {
+		return false
+	}
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		if !field.IsExported() {
+			// A nil pointer to an unexported type can't be allocated when decoding
+			return false
+		}
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct {
+		return false
+	}
+	ptrType := reflect.PointerTo(fieldType)
+	for _, iface := range []reflect.Type{tOfMarshalCSV, tOfUnmarshalCSV, tOfTextMarshaller, tOfTextUnmarshaler, tOfCSVNullable, tOfFromStringer} {
+		if ptrType.Implements(iface) {
+			return false
+		}
+	}
+	return true
+}
+
+// layoutError gets the error for a record type whose fields can't be mapped to columns.
+func layoutError(instructions *rcache.FieldCache[csvInstruction]) error {
+	return getRecordLayout(instructions).err
+}
+
+// columnField gets the field for a header, falling back to the alias= names of each field.
+// Fields tagged with lineno never match a header.
+func columnField(instructions *rcache.FieldCache[csvInstruction], header string) *recordField {
+	layout := getRecordLayout(instructions)
+	field := layout.byName[header]
+	if field == nil {
+		field = layout.aliasedField(header)
+	}
+	if field == nil || field.InstructionData().IsLineNo() {
+		return nil
+	}
+	return field
+}
+
+// aliasedField gets the field that lists the header as one of its aliases.
+func (l *recordLayout) aliasedField(header string) *recordField {
+	for _, field := range l.fields {
+		if slices.Contains(field.InstructionData().GetAliases(), header) {
+			return field
+		}
+	}
+	return nil
+}
+
+// columnFields gets the fields that map to columns, leaving out fields tagged with lineno.
+func columnFields(instructions *rcache.FieldCache[csvInstruction]) []*recordField {
+	var out []*recordField
+	for _, field := range getRecordLayout(instructions).fields {
+		if !field.InstructionData().IsLineNo() {
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+// lineNoFields gets the fields tagged with lineno.
+func lineNoFields(instructions *rcache.FieldCache[csvInstruction]) []*recordField {
+	var out []*recordField
+	for _, field := range getRecordLayout(instructions).fields {
+		if field.InstructionData().IsLineNo() {
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+// extrasField gets the field tagged with extras, or nil if the record doesn't have one.
+func extrasField(instructions *rcache.FieldCache[csvInstruction]) *recordField {
+	return getRecordLayout(instructions).extras
+}
@@ -0,0 +1,68 @@
+package csv
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// floatFormats are the strconv.FormatFloat formats accepted by the fmt= tag option
+const floatFormats = "feEgG"
+
+// getFloatEncoder returns an encoder that writes a float field with a fixed number of decimal places from the prec= tag option.
+// With smartPrec, integral values are written in their shortest form and only fractional values use the precision.
+// format is the strconv.FormatFloat format from the fmt= tag option, 'f' is used if it is empty.
+// float32 fields are formatted with a bitSize of 32 so they don't pick up digits from the conversion to float64.
+func getFloatEncoder(fieldType reflect.Type, fieldName string, prec string, format string, smartPrec bool, omitEmpty bool) encoderFunction {
+	valType := fieldType
+	if valType.Kind() == reflect.Ptr {
+		valType = valType.Elem()
+	}
+	if valType.Kind() != reflect.Float32 && valType.Kind() != reflect.Float64 {
+		return func(val reflect.Value) (string, error) {
+			return "", fmt.Errorf("%v can only use prec= or fmt= with a float, not %v", fieldName, valType.Kind())
+		}
+	}
+	precision := -1
+	if len(prec) > 0 {
+		var err error
+		precision, err = strconv.Atoi(prec)
+		if err != nil || precision < 0 {
+			return func(val reflect.Value) (string, error) {
+				return "", fmt.Errorf("%v has an invalid prec= value %q", fieldName, prec)
+			}
+		}
+	}
+	var formatByte byte = 'f'
+	if len(format) > 0 {
+		if len(format) != 1 || !strings.Contains(floatFormats, format) {
+			return func(val reflect.Value) (string, error) {
+				return "", fmt.Errorf("%v has an invalid fmt= value %q, expected one of %v", fieldName, format, strings.Join(strings.Split(floatFormats, ""), ", "))
+			}
+		}
+		formatByte = format[0]
+	}
+	bitSize := 64
+	if valType.Kind() == reflect.Float32 {
+		bitSize = 32
+	}
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		if val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return "", nil
+			}
+			val = val.Elem()
+		}
+		f := val.Float()
+		if smartPrec && f == math.Trunc(f) {
+			return strconv.FormatFloat(f, formatByte, -1, bitSize), nil
+		}
+		return strconv.FormatFloat(f, formatByte, precision, bitSize), nil
+	}
+}
@@ -0,0 +1,88 @@
+package csv
+
+import (
+	"bytes"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type precisionRecord struct {
+	Fixed float64 `csv:"fixed,prec=2"`
+	Smart float64 `csv:"smart,prec=2,smartprec"`
+}
+
+type floatFormatRecord struct {
+	Money      float32 `csv:"money,precision=2"`
+	Scientific float64 `csv:"scientific,fmt=e,prec=3"`
+	Shortest   float32 `csv:"shortest,fmt=g"`
+}
+
+type invalidFloatFormatRecord struct {
+	Value float64 `csv:"value,fmt=x"`
+}
+
+type invalidPrecisionRecord struct {
+	Name string `csv:"name,prec=2"`
+}
+
+func TestFloatPrecision(t *testing.T) {
+	t.Run("prec", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[precisionRecord](&buf)
+		require.NoError(writer.WriteRecord(
+			precisionRecord{Fixed: 42.0, Smart: 42.0},
+			precisionRecord{Fixed: 42.5, Smart: 42.5},
+			precisionRecord{Fixed: 0.125, Smart: -3},
+		))
+		require.NoError(writer.Flush())
+		require.Equal("fixed,smart\n42.00,42\n42.50,42.50\n0.12,-3\n", buf.String())
+	})
+	t.Run("precision and fmt", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[floatFormatRecord](&buf)
+		require.NoError(writer.WriteRecord(
+			floatFormatRecord{Money: 5125.23, Scientific: 1234.5678, Shortest: 5125.23},
+			floatFormatRecord{Money: 0.1, Scientific: 0, Shortest: 1e21},
+		))
+		require.NoError(writer.Flush())
+		// float32 fields are formatted without the digits picked up by widening to float64
+		require.Equal("money,scientific,shortest\n5125.23,1.235e+03,5125.23\n0.10,0.000e+00,1e+21\n", buf.String())
+	})
+	t.Run("invalid fmt", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[invalidFloatFormatRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(invalidFloatFormatRecord{}), `value has an invalid fmt= value "x", expected one of f, e, E, g, G`)
+	})
+	t.Run("non-float", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[invalidPrecisionRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(invalidPrecisionRecord{}), "name can only use prec= or fmt= with a float, not string")
+	})
+}
+
+func TestFloat32RoundTrip(t *testing.T) {
+	type float32Record struct {
+		Value float32 `csv:"value"`
+	}
+	require := testifyrequire.New(t)
+	values := []float32{5125.23, 0.1, 1.1, -3.3, 16777216, 3.4028235e38, 1e-45, 0}
+	var records []float32Record
+	for _, v := range values {
+		records = append(records, float32Record{Value: v})
+	}
+	buf := bytes.Buffer{}
+	writer := NewWriter[float32Record](&buf)
+	require.NoError(writer.WriteRecord(records...))
+	require.NoError(writer.Flush())
+	require.Contains(buf.String(), "\n5125.23\n")
+	require.Contains(buf.String(), "\n0.1\n")
+	require.NotContains(buf.String(), "5125.2299")
+
+	reader := NewStructuredCSVReader[float32Record](&buf)
+	out, err := readAllRecords(reader)
+	require.NoError(err)
+	require.Equal(records, out)
+}
@@ -7,6 +7,14 @@ type UnmarshalCSV interface {
 	UnmarshalCSV(data string) error
 }
 
+// CSVNullable provides an interface for third-party nullable types to decode a csv field.
+// SetNull is called for a null cell and SetValue is called with the cell value otherwise.
+// UnmarshalCSV takes precedence if a type implements both.
+type CSVNullable interface {
+	SetNull()
+	SetValue(data string) error
+}
+
 // MarshalCSV provides an interface to encode non-scalar values to a CSV.
 type MarshalCSV interface {
 	MarshalCSV() (string, error)
@@ -17,6 +25,11 @@ type Stringer interface {
 	String() string
 }
 
+// FromStringer provides an interface for decoding from a string, the counterpart to Stringer.
+type FromStringer interface {
+	FromString(data string) error
+}
+
 // Zeroer provides an interface to check if an object is in its zero state.
 type Zeroer interface {
 	IsZero() bool
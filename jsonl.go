@@ -0,0 +1,68 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/weisbartb/stack"
+)
+
+// JSONLinesToCSV converts JSON lines (one JSON object per line) into a CSV of the given record type.
+// Each line is decoded into a Record and written through a Writer, the header is always emitted once.
+// Blank lines are skipped, errors include the line number of the input they occurred on.
+func JSONLinesToCSV[Record any](in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	writer := NewWriter[Record](out)
+	var line int
+	for {
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return stack.Wrap(readErr, fmt.Sprintf("reading line %v", line+1))
+		}
+		if len(data) > 0 {
+			line++
+		}
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			var record Record
+			if err := json.Unmarshal(data, &record); err != nil {
+				return stack.Wrap(err, fmt.Sprintf("decoding line %v", line))
+			}
+			if err := writer.WriteRecord(record); err != nil {
+				return stack.Wrap(err, fmt.Sprintf("writing line %v", line))
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if !writer.headerWritten {
+		// Nothing was written, still emit the header so the output is a valid CSV
+		if err := writer.WriteRecord(); err != nil {
+			return stack.Trace(err)
+		}
+	}
+	return stack.Trace(writer.Flush())
+}
+
+// CSVToJSONLines converts a CSV of the given record type into JSON lines (one JSON object per line).
+// Each row is decoded through a Reader, so decode errors include the CSV row number.
+func CSVToJSONLines[Record any](in io.Reader, out io.Writer) error {
+	reader := NewStructuredCSVReader[Record](in)
+	encoder := json.NewEncoder(out)
+	for {
+		record, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return stack.Trace(err)
+		}
+		if err := encoder.Encode(record); err != nil {
+			return stack.Wrap(err, fmt.Sprintf("encoding row %v", reader.currentRow))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type jsonLinesRecord struct {
+	Name string  `json:"name" csv:"name"`
+	Age  int     `json:"age" csv:"age"`
+	Owed float64 `json:"owed" csv:"owed"`
+}
+
+func TestJSONLinesToCSV(t *testing.T) {
+	t.Run("converts", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		err := JSONLinesToCSV[jsonLinesRecord](strings.NewReader(
+			"{\"name\":\"bob\",\"age\":32,\"owed\":12.5}\n\n{\"name\":\"alice\",\"age\":41}",
+		), &buf)
+		require.NoError(err)
+		require.Equal("name,age,owed\nbob,32,12.5\nalice,41,0\n", buf.String())
+	})
+	t.Run("empty input", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		require.NoError(JSONLinesToCSV[jsonLinesRecord](strings.NewReader(""), &buf))
+		require.Equal("name,age,owed\n", buf.String())
+	})
+	t.Run("bad line", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		err := JSONLinesToCSV[jsonLinesRecord](strings.NewReader(
+			"{\"name\":\"bob\"}\n\n{\"name\":1}\n",
+		), &buf)
+		require.ErrorContains(err, "decoding line 3: json: cannot unmarshal number")
+	})
+}
+
+func TestCSVToJSONLines(t *testing.T) {
+	t.Run("converts", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		fh, err := testData.Open("testdata/simple-required.csv")
+		require.NoError(err)
+		require.NoError(CSVToJSONLines[simpleNullableCSVRecord](fh, &buf))
+		require.Equal(
+			"{\"AString\":\"string\",\"AFloat\":523.52,\"AnInt\":11,\"ABool\":null}\n"+
+				"{\"AString\":\"string\",\"AFloat\":523.52,\"AnInt\":null,\"ABool\":false}\n",
+			buf.String(),
+		)
+	})
+	t.Run("bad row", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		err := CSVToJSONLines[jsonLinesRecord](strings.NewReader("name,age\nbob,32\nalice,old\n"), &buf)
+		require.ErrorContains(err, "on row 3: column \"age\": strconv.ParseInt")
+		require.Equal("{\"name\":\"bob\",\"age\":32,\"owed\":0}\n", buf.String())
+	})
+}
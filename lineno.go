@@ -0,0 +1,26 @@
+package csv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// setLineNo sets a field tagged with lineno to the row number, a pointer field is allocated to hold it.
+func setLineNo(field reflect.Value, row int) error {
+	if field.Kind() == reflect.Ptr {
+		if fieldKind(field.Type()) == reflect.Ptr {
+			return fmt.Errorf("lineno can only be used with an integer field, not %v", field.Type())
+		}
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(int64(row))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(row))
+	default:
+		return fmt.Errorf("lineno can only be used with an integer field, not %v", field.Type())
+	}
+	return nil
+}
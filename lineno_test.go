@@ -0,0 +1,59 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type lineNoRecord struct {
+	Line int    `csv:",lineno"`
+	Name string `csv:"name"`
+}
+
+func TestLineNo(t *testing.T) {
+	t.Run("reader", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[lineNoRecord](strings.NewReader("name,Line\na,99\n# skipped\nb,98\n"))
+		reader.CommentPrefix = "#"
+		reader.StrictMode = true
+		reader.AllowedExtraColumns = []string{"Line"}
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		// The Line column is not matched to the field
+		require.Equal([]lineNoRecord{{Line: 2, Name: "a"}, {Line: 4, Name: "b"}}, records)
+	})
+	t.Run("writer", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[lineNoRecord](&buf)
+		require.NoError(writer.WriteRecord(lineNoRecord{Line: 5, Name: "a"}))
+		require.NoError(writer.Flush())
+		require.Equal("name\na\n", buf.String())
+		require.Len(DescribeColumns[lineNoRecord](), 1)
+	})
+	t.Run("pointer", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		type pointerLineNoRecord struct {
+			Line *int   `csv:",lineno"`
+			Name string `csv:"name"`
+		}
+		reader := NewStructuredCSVReader[pointerLineNoRecord](strings.NewReader("name\na\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.NotNil(record.Line)
+		require.Equal(2, *record.Line)
+	})
+	t.Run("non-integer", func(t *testing.T) {
+		type invalidLineNoRecord struct {
+			Line string `csv:",lineno"`
+			Name string `csv:"name"`
+		}
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[invalidLineNoRecord](strings.NewReader("name\na\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: lineno can only be used with an integer field, not string")
+	})
+}
@@ -0,0 +1,144 @@
+package csv
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var tOfTime = reflect.TypeFor[time.Time]()
+
+// Locale bundles the formatting conventions of spreadsheet exports from a given locale.
+// Apply it with SetLocale on a Reader or Writer.
+type Locale struct {
+	// Delimiter is the field separator, 0 keeps the current delimiter
+	Delimiter rune
+	// DecimalSeparator separates the whole and fractional part of a number
+	DecimalSeparator rune
+	// GroupingSeparators contain every rune used to group thousands, these are removed when decoding
+	GroupingSeparators string
+	// TrueValues are the words for true, the first is used when encoding
+	TrueValues []string
+	// FalseValues are the words for false, the first is used when encoding
+	FalseValues []string
+	// DateLayouts are tried in order when decoding time.Time fields.
+	// Encoding uses the first layout that keeps the whole time, so a date-only layout is only used for midnight.
+	DateLayouts []string
+}
+
+// LocaleDE covers files from German Excel.
+var LocaleDE = Locale{
+	Delimiter:          ';',
+	DecimalSeparator:   ',',
+	GroupingSeparators: ".",
+	TrueValues:         []string{"WAHR", "ja"},
+	FalseValues:        []string{"FALSCH", "nein"},
+	DateLayouts:        []string{"02.01.2006", "02.01.2006 15:04:05", "02.01.2006 15:04"},
+}
+
+// LocaleFR covers files from French Excel.
+var LocaleFR = Locale{
+	Delimiter:          ';',
+	DecimalSeparator:   ',',
+	GroupingSeparators: " \u00a0\u202f",
+	TrueValues:         []string{"VRAI", "oui"},
+	FalseValues:        []string{"FAUX", "non"},
+	DateLayouts:        []string{"02/01/2006", "02/01/2006 15:04:05", "02/01/2006 15:04"},
+}
+
+// delocalizeCell converts a localized cell into the form the default decoders expect.
+// Cells that can't be converted are returned as-is so the decoder reports the error.
+func (l *Locale) delocalizeCell(fieldType reflect.Type, cell string) string {
+	if len(cell) == 0 {
+		return cell
+	}
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == tOfTime {
+		for _, layout := range l.DateLayouts {
+			if t, err := time.Parse(layout, cell); err == nil {
+				return t.Format(time.RFC3339Nano)
+			}
+		}
+		return cell
+	}
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return l.removeGrouping(cell)
+	case reflect.Float32, reflect.Float64:
+		cell = l.removeGrouping(cell)
+		if l.DecimalSeparator != 0 {
+			cell = strings.ReplaceAll(cell, string(l.DecimalSeparator), ".")
+		}
+		return cell
+	case reflect.Bool:
+		for _, v := range l.TrueValues {
+			if strings.EqualFold(v, cell) {
+				return "true"
+			}
+		}
+		for _, v := range l.FalseValues {
+			if strings.EqualFold(v, cell) {
+				return "false"
+			}
+		}
+	}
+	return cell
+}
+
+// localizeCell converts an encoded cell into the locale's format.
+func (l *Locale) localizeCell(fieldVal reflect.Value, cell string) string {
+	if len(cell) == 0 {
+		return cell
+	}
+	if fieldVal.Kind() == reflect.Ptr {
+		fieldVal = fieldVal.Elem()
+	}
+	if fieldVal.Type() == tOfTime {
+		if formatted, ok := l.formatTime(fieldVal.Interface().(time.Time)); ok {
+			return formatted
+		}
+		// None of the layouts keep the time, the default format is still read back by the decoder
+		return cell
+	}
+	switch fieldVal.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if l.DecimalSeparator != 0 {
+			return strings.Replace(cell, ".", string(l.DecimalSeparator), 1)
+		}
+	case reflect.Bool:
+		if fieldVal.Bool() && len(l.TrueValues) > 0 {
+			return l.TrueValues[0]
+		} else if !fieldVal.Bool() && len(l.FalseValues) > 0 {
+			return l.FalseValues[0]
+		}
+	}
+	return cell
+}
+
+// formatTime formats a time with the first date layout that reads back as the same wall clock time.
+func (l *Locale) formatTime(t time.Time) (string, bool) {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	for _, layout := range l.DateLayouts {
+		formatted := t.Format(layout)
+		if parsed, err := time.Parse(layout, formatted); err == nil && parsed.Equal(wall) {
+			return formatted, true
+		}
+	}
+	return "", false
+}
+
+// removeGrouping strips the grouping separators from a number.
+func (l *Locale) removeGrouping(cell string) string {
+	if len(l.GroupingSeparators) == 0 {
+		return cell
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(l.GroupingSeparators, r) {
+			return -1
+		}
+		return r
+	}, cell)
+}
@@ -0,0 +1,126 @@
+package csv
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type localeRecord struct {
+	Name    string    `csv:"name"`
+	Amount  float64   `csv:"amount"`
+	Count   int       `csv:"count"`
+	Active  bool      `csv:"active"`
+	Created time.Time `csv:"created"`
+}
+
+type localeTaggedRecord struct {
+	Seen     time.Time  `csv:"seen,unix"`
+	Day      time.Time  `csv:"day,format=2006-01-02"`
+	Enabled  bool       `csv:"enabled,truevalues=yes,falsevalues=no"`
+	Currency localeCode `csv:"currency"`
+}
+
+// localeCode has a codec registered in TestLocale, so the locale must leave its cells alone.
+type localeCode float64
+
+func TestLocale(t *testing.T) {
+	t.Run("german round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		input := "name;amount;count;active;created\n" +
+			"Müller;1.234,56;1.000;WAHR;31.12.2024\n" +
+			"Schmidt;-0,5;7;nein;01.02.2023 13:14:15\n"
+		reader := NewStructuredCSVReader[localeRecord](strings.NewReader(input))
+		reader.SetLocale(LocaleDE)
+		records, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal([]localeRecord{
+			{Name: "Müller", Amount: 1234.56, Count: 1000, Active: true, Created: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)},
+			{Name: "Schmidt", Amount: -0.5, Count: 7, Active: false, Created: time.Date(2023, 2, 1, 13, 14, 15, 0, time.UTC)},
+		}, records)
+
+		buf := bytes.Buffer{}
+		writer := NewWriter[localeRecord](&buf)
+		writer.SetLocale(LocaleDE)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("name;amount;count;active;created\n"+
+			"Müller;1234,56;1000;WAHR;31.12.2024\n"+
+			"Schmidt;-0,5;7;FALSCH;01.02.2023 13:14:15\n", buf.String())
+
+		reader = NewStructuredCSVReader[localeRecord](&buf)
+		reader.SetLocale(LocaleDE)
+		roundTrip, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal(records, roundTrip)
+	})
+	t.Run("french grouping", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[localeRecord](strings.NewReader("amount;count;active\n1\u00a0234,5;2\u202f000;vrai\n"))
+		reader.SetLocale(LocaleFR)
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(1234.5, record.Amount)
+		require.Equal(2000, record.Count)
+		require.True(record.Active)
+	})
+	t.Run("time of day", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[localeRecord](&buf)
+		writer.SetLocale(LocaleFR)
+		require.NoError(writer.WriteAll([]localeRecord{
+			{Created: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+			{Created: time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)},
+			{Created: time.Date(2024, 3, 5, 9, 30, 0, 500, time.UTC)},
+		}))
+		// Midnight uses the date-only layout, a time the layouts can't hold keeps the default format
+		require.Equal("name;amount;count;active;created\n"+
+			";0;0;FAUX;05/03/2024\n"+
+			";0;0;FAUX;05/03/2024 09:30:00\n"+
+			";0;0;FAUX;2024-03-05T09:30:00.0000005Z\n", buf.String())
+
+		reader := NewStructuredCSVReader[localeRecord](&buf)
+		reader.SetLocale(LocaleFR)
+		records, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal(time.Date(2024, 3, 5, 9, 30, 0, 500, time.UTC), records[2].Created)
+	})
+	t.Run("tagged fields keep their format", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		RegisterCodec(func(val localeCode) (string, error) {
+			return strconv.FormatFloat(float64(val), 'f', 2, 64), nil
+		}, func(s string) (localeCode, error) {
+			val, err := strconv.ParseFloat(s, 64)
+			return localeCode(val), err
+		})
+		record := localeTaggedRecord{
+			Seen:     time.Unix(1700000000, 0).UTC(),
+			Day:      time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+			Enabled:  true,
+			Currency: 1.5,
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[localeTaggedRecord](&buf)
+		writer.SetLocale(LocaleDE)
+		require.NoError(writer.WriteAll([]localeTaggedRecord{record}))
+		require.Equal("seen;day;enabled;currency\n1700000000;2024-03-05;yes;1.50\n", buf.String())
+
+		reader := NewStructuredCSVReader[localeTaggedRecord](&buf)
+		reader.SetLocale(LocaleDE)
+		out, err := reader.Next()
+		require.NoError(err)
+		require.Equal(record, out)
+	})
+	t.Run("tagged layout is not rewritten", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[localeTaggedRecord](strings.NewReader("day\n05.03.2024\n"))
+		reader.SetLocale(LocaleDE)
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "day": day is not a valid time: parsing time "05.03.2024" as "2006-01-02": cannot parse "05.03.2024" as "2006"`)
+	})
+}
@@ -0,0 +1,86 @@
+package csv
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Default separators for map fields, e.g. k1=v1;k2=v2
+const (
+	defaultMapPairSeparator = ";"
+	defaultMapKVSeparator   = "="
+)
+
+// isMapField checks if a field should use the key/value map codec.
+// Maps implementing their own marshalling interfaces are left to those interfaces.
+func isMapField(fieldType reflect.Type) bool {
+	if fieldType.Kind() != reflect.Map || fieldType.Key().Kind() != reflect.String {
+		return false
+	}
+	ptrType := reflect.PointerTo(fieldType)
+	return !fieldType.Implements(tOfMarshalCSV) && !ptrType.Implements(tOfUnmarshalCSV) &&
+		!fieldType.Implements(tOfTextMarshaller) && !ptrType.Implements(tOfTextUnmarshaler)
+}
+
+// getMapEncoder returns an encoder that joins a map into key/value pairs.
+// Keys are sorted so the output is stable.
+func getMapEncoder(fieldType reflect.Type, pairSep, kvSep string) encoderFunction {
+	valueEncoder := getEncoderProvider(fieldType.Elem(), false)
+	return func(val reflect.Value) (string, error) {
+		if val.Len() == 0 {
+			// A nil or empty map is always written as null
+			return "", nil
+		}
+		keys := make([]string, 0, val.Len())
+		for _, key := range val.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		slices.Sort(keys)
+		var out strings.Builder
+		for i, key := range keys {
+			encoded, err := valueEncoder(val.MapIndex(reflect.ValueOf(key).Convert(fieldType.Key())))
+			if err != nil {
+				return "", err
+			}
+			if i > 0 {
+				out.WriteString(pairSep)
+			}
+			out.WriteString(key)
+			out.WriteString(kvSep)
+			out.WriteString(encoded)
+		}
+		return out.String(), nil
+	}
+}
+
+// getMapDecoder returns a decoder that splits key/value pairs into a map.
+// An empty cell yields a nil map.
+func getMapDecoder(fieldType reflect.Type, fieldName string, required bool, pairSep, kvSep string) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	valueDecoder := getDecoderProvider(fieldType.Elem(), fieldName, false)
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		out := reflect.Zero(fieldType)
+		if len(s) == 0 {
+			return out.Interface(), nil
+		}
+		pairs := strings.Split(s, pairSep)
+		out = reflect.MakeMapWithSize(fieldType, len(pairs))
+		for _, pair := range pairs {
+			key, value, ok := strings.Cut(pair, kvSep)
+			if !ok {
+				return nil, fmt.Errorf("%v has a malformed key/value pair %q", fieldName, pair)
+			}
+			decoded, err := valueDecoder(value, len(value) == 0)
+			if err != nil {
+				return nil, fmt.Errorf("%v key %q: %w", fieldName, key, err)
+			}
+			out.SetMapIndex(reflect.ValueOf(key).Convert(fieldType.Key()), reflect.ValueOf(decoded).Convert(fieldType.Elem()))
+		}
+		return out.Interface(), nil
+	}
+}
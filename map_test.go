@@ -0,0 +1,68 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type mapRecord struct {
+	ID     int               `csv:"id"`
+	Labels map[string]string `csv:"labels"`
+	Counts map[string]int    `csv:"counts,sep=|,kv=:"`
+}
+
+func TestMapFields(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[mapRecord](&buf)
+		err := writer.WriteRecord(mapRecord{
+			ID:     1,
+			Labels: map[string]string{"k2": "v2", "k1": "v1"},
+			Counts: map[string]int{"b": 2, "a": 1},
+		}, mapRecord{ID: 2})
+		require.NoError(err)
+		require.NoError(writer.Flush())
+		require.Equal("id,labels,counts\n1,k1=v1;k2=v2,a:1|b:2\n2,,\n", buf.String())
+
+		reader := NewStructuredCSVReader[mapRecord](&buf)
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(map[string]string{"k1": "v1", "k2": "v2"}, record.Labels)
+		require.Equal(map[string]int{"a": 1, "b": 2}, record.Counts)
+		record, err = reader.Next()
+		require.NoError(err)
+		require.Nil(record.Labels)
+		require.Nil(record.Counts)
+	})
+	t.Run("malformed pair", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[mapRecord](strings.NewReader("id,labels\n1,k1=v1;k2\n"))
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "labels": labels has a malformed key/value pair "k2"`)
+	})
+	t.Run("bad value", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[mapRecord](strings.NewReader("id,counts\n1,a:x\n"))
+		_, err := reader.Next()
+		require.ErrorContains(err, `counts key "a"`)
+	})
+	t.Run("pointer values", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		type pointerMapRecord struct {
+			ID     int             `csv:"id"`
+			Counts map[string]*int `csv:"counts"`
+		}
+		reader := NewStructuredCSVReader[pointerMapRecord](strings.NewReader("id,counts\n1,a=1\n"))
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "counts": counts is a map[string]*int, map values can not be pointers`)
+
+		one := 1
+		writer := NewWriter[pointerMapRecord](&bytes.Buffer{})
+		err = writer.WriteRecord(pointerMapRecord{Counts: map[string]*int{"a": &one}})
+		require.EqualError(err, "counts is a map[string]*int, map values can not be pointers")
+	})
+}
@@ -0,0 +1,92 @@
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// getNestedEncoder returns an encoder that writes a struct field as a CSV of its own (a header and a single row) in one cell.
+// The cell is written by a Writer for the struct, so the nested fields are encoded the same as a record's, including extras.
+// The outer csv.Writer quotes the cell since it holds newlines, so the outer CSV stays valid.
+func getNestedEncoder(fieldType reflect.Type, fieldName string, omitEmpty bool) encoderFunction {
+	if fieldType.Kind() != reflect.Struct {
+		return func(val reflect.Value) (string, error) {
+			return "", fmt.Errorf("%v can only use nested with a struct, not %v", fieldName, fieldType)
+		}
+	}
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		buf := bytes.Buffer{}
+		writer := newWriter[any](&buf, fieldCache.GetTypeDataFor(fieldType))
+		if err := writer.WriteAll([]any{val.Interface()}); err != nil {
+			return "", fmt.Errorf("%v: %w", fieldName, err)
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	}
+}
+
+// getNestedDecoder returns a decoder that reads a struct field from a CSV of its own (a header and a single row) in one cell.
+// The cell is read by a Reader for the struct, so required columns, defaults, and extras work the same as for a record.
+// An empty cell yields the zero value of the struct.
+func getNestedDecoder(fieldType reflect.Type, fieldName string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	if fieldType.Kind() != reflect.Struct {
+		return func(s string, isNull bool) (any, error) {
+			return nil, fmt.Errorf("%v can only use nested with a struct, not %v", fieldName, fieldType)
+		}
+	}
+	errRowCount := fmt.Errorf("%v must hold a header and a single row", fieldName)
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		out := reflect.New(fieldType).Elem()
+		if len(s) == 0 {
+			return out.Interface(), nil
+		}
+		reader := newReader[any](strings.NewReader(s), fieldCache.GetTypeDataFor(fieldType))
+		if err := reader.initialize(); err != nil {
+			return nil, fmt.Errorf("%v: %w", fieldName, err)
+		}
+		row, err := reader.nextRow()
+		if errors.Is(err, io.EOF) {
+			return nil, errRowCount
+		} else if err != nil {
+			return nil, fmt.Errorf("%v: %w", fieldName, err)
+		}
+		if err := reader.decodeInto(out, row, reader.currentRow); err != nil {
+			return nil, nestedDecodeError(fieldName, err)
+		}
+		if _, err := reader.nextRow(); !errors.Is(err, io.EOF) {
+			return nil, errRowCount
+		}
+		return out.Interface(), nil
+	}
+}
+
+// nestedDecodeError drops the row from an error decoding a nested cell, the row within the cell is always the same.
+// The outer reader adds the row and column of the cell itself.
+func nestedDecodeError(fieldName string, err error) error {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return fmt.Errorf("%v: column %q: %w", fieldName, decodeErr.Column, decodeErr.Err)
+	}
+	return fmt.Errorf("%v: %w", fieldName, err)
+}
+
+// setNestedLineNo sets the lineno fields of a decoded nested struct to the row of the record that holds it.
+func setNestedLineNo(field reflect.Value, rowNumber int) error {
+	for _, lineNoField := range lineNoFields(fieldCache.GetTypeDataFor(field.Type())) {
+		if err := setLineNo(lineNoField.settableOf(field), rowNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type nestedDetail struct {
+	Street string `csv:"street"`
+	City   string `csv:"city"`
+	Floor  int    `csv:"floor"`
+}
+
+type nestedRecord struct {
+	Name   string       `csv:"name"`
+	Detail nestedDetail `csv:"detail,nested"`
+}
+
+type nestedOptionsDetail struct {
+	City    string            `csv:"city,required"`
+	Country string            `csv:"country,default=US"`
+	Floor   int               `csv:"floor"`
+	Line    int               `csv:",lineno"`
+	Extra   map[string]string `csv:",extras"`
+}
+
+type nestedOptionsRecord struct {
+	Name   string              `csv:"name"`
+	Detail nestedOptionsDetail `csv:"detail,nested"`
+}
+
+type invalidNestedRecord struct {
+	Name string `csv:"name,nested"`
+}
+
+func TestNested(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		records := []nestedRecord{
+			{Name: "alice", Detail: nestedDetail{Street: `1 "Main", St`, City: "Springfield", Floor: 2}},
+			{Name: "bob"},
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[nestedRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal(
+			"name,detail\n"+
+				"alice,\"street,city,floor\n\"\"1 \"\"\"\"Main\"\"\"\", St\"\",Springfield,2\"\n"+
+				"bob,\"street,city,floor\n,,0\"\n",
+			buf.String(),
+		)
+
+		reader := NewStructuredCSVReader[nestedRecord](&buf)
+		out, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal(records, out)
+	})
+	t.Run("columns in any order", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nestedRecord](strings.NewReader("name,detail\nalice,\"floor,city\n3,Springfield\"\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(nestedRecord{Name: "alice", Detail: nestedDetail{City: "Springfield", Floor: 3}}, record)
+	})
+	t.Run("invalid cell", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nestedRecord](strings.NewReader("name,detail\nalice,floor\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"detail\": detail must hold a header and a single row")
+		reader = NewStructuredCSVReader[nestedRecord](strings.NewReader("name,detail\nalice,\"floor\n1\n2\"\n"))
+		_, err = reader.Next()
+		require.EqualError(err, "on row 2: column \"detail\": detail must hold a header and a single row")
+		reader = NewStructuredCSVReader[nestedRecord](strings.NewReader("name,detail\nalice,\"floor\nthree\"\n"))
+		_, err = reader.Next()
+		require.ErrorContains(err, "on row 2: column \"detail\": detail: ")
+	})
+	t.Run("non-struct", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[invalidNestedRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(invalidNestedRecord{}), "name can only use nested with a struct, not string")
+	})
+	t.Run("required column", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nestedOptionsRecord](strings.NewReader("name,detail\nalice,\"floor\n3\"\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"detail\": detail: city is a required column but was not seen in the csv")
+	})
+	t.Run("bad value", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nestedOptionsRecord](strings.NewReader("name,detail\nalice,\"city,floor\nParis,three\"\n"))
+		_, err := reader.Next()
+		require.ErrorContains(err, "on row 2: column \"detail\": detail: column \"floor\": ")
+	})
+	t.Run("defaults, lineno, and extras", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nestedOptionsRecord](strings.NewReader(
+			"name,detail\n" +
+				"alice,\"city,floor,zip\nParis,3,75001\"\n" +
+				"bob,\"city,country\nBerlin,DE\"\n",
+		))
+		records, err := readAllRecords(reader)
+		require.NoError(err)
+		// A missing column takes its default and lineno is the row of the outer record
+		require.Equal([]nestedOptionsRecord{
+			{Name: "alice", Detail: nestedOptionsDetail{City: "Paris", Country: "US", Floor: 3, Line: 2, Extra: map[string]string{"zip": "75001"}}},
+			{Name: "bob", Detail: nestedOptionsDetail{City: "Berlin", Country: "DE", Line: 3}},
+		}, records)
+
+		// The extras are written back as columns of the nested CSV
+		buf := bytes.Buffer{}
+		writer := NewWriter[nestedOptionsRecord](&buf)
+		require.NoError(writer.WriteAll(records[:1]))
+		require.Equal("name,detail\nalice,\"city,country,floor,zip\nParis,US,3,75001\"\n", buf.String())
+	})
+}
@@ -1,6 +1,7 @@
 package csv
 
 import (
+	"encoding/json"
 	"errors"
 	"reflect"
 )
@@ -43,6 +44,11 @@ func (n NullableField[T]) IsNull() bool {
 	return len(n) == 0
 }
 
+// IsZero implements Zeroer so omitempty treats an unset field as empty, a field set to its zero value is not empty.
+func (n NullableField[T]) IsZero() bool {
+	return len(n) == 0
+}
+
 // Set updates the nullable field value.
 func (n *NullableField[T]) Set(val T) {
 	if len(*n) > 0 {
@@ -71,3 +77,25 @@ func (n NullableField[T]) Get() (T, bool) {
 	}
 	return n[0], true
 }
+
+// MarshalJSON encodes an unset field as null and a set field as its underlying value.
+func (n NullableField[T]) MarshalJSON() ([]byte, error) {
+	if len(n) == 0 {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n[0])
+}
+
+// UnmarshalJSON decodes null as an unset field and anything else as the underlying value.
+func (n *NullableField[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		n.Unset()
+		return nil
+	}
+	var val T
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	n.Set(val)
+	return nil
+}
@@ -1,7 +1,11 @@
 package csv
 
 import (
-	"encoding/csv"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	testifyrequire "github.com/stretchr/testify/require"
@@ -31,8 +35,7 @@ func TestNullableCSV(t *testing.T) {
 	require := testifyrequire.New(t)
 	fh, err := testData.Open("testdata/simple.csv")
 	require.NoError(err)
-	csvReader := csv.NewReader(fh)
-	reader, err := NewStructuredCSVReader[simpleNullableCSVRecord](csvReader)
+	reader := NewStructuredCSVReader[simpleNullableCSVRecord](fh)
 	record, err := reader.Next()
 	require.NoError(err)
 	require.NotEmpty(record)
@@ -41,3 +44,85 @@ func TestNullableCSV(t *testing.T) {
 	require.Equal(523.52, selectNullVal(record.AFloat.Get()))
 	require.Equal("string", selectNullVal(record.AString.Get()))
 }
+
+func TestNullableOmitEmpty(t *testing.T) {
+	type omitNullableRecord struct {
+		Count NullableField[int] `csv:"count,omitempty"`
+	}
+	require := testifyrequire.New(t)
+	// An unset field is empty whether it is nil or an empty slice, a field set to its zero value is not
+	zeroFunc := getZeroFunction(reflect.TypeFor[NullableField[int]]())
+	require.True(zeroFunc(reflect.ValueOf(NullableField[int](nil))))
+	require.True(zeroFunc(reflect.ValueOf(NullableField[int]{})))
+	require.False(zeroFunc(reflect.ValueOf(NullableField[int]{0})))
+
+	buf := bytes.Buffer{}
+	writer := NewWriter[omitNullableRecord](&buf)
+	writer.SkipEmptyRows(true)
+	require.NoError(writer.WriteRecord(
+		omitNullableRecord{Count: NullableField[int]{}},
+		omitNullableRecord{Count: NullableField[int]{0}},
+	))
+	require.NoError(writer.Flush())
+	require.Equal("count\n0\n", buf.String())
+}
+
+func TestNullableJSON(t *testing.T) {
+	require := testifyrequire.New(t)
+	var record struct {
+		Set   NullableField[int] `json:"set"`
+		Unset NullableField[int] `json:"unset"`
+	}
+	record.Set.Set(5)
+	data, err := json.Marshal(record)
+	require.NoError(err)
+	require.Equal(`{"set":5,"unset":null}`, string(data))
+	record.Set.Unset()
+	record.Unset.Set(1)
+	require.NoError(json.Unmarshal(data, &record))
+	require.Equal(5, selectNullVal(record.Set.Get()))
+	require.True(record.Unset.IsNull())
+}
+
+// customNullable is a third-party style nullable wrapper
+type customNullable struct {
+	Value string
+	Valid bool
+	// setNullCalled tracks that SetNull was used rather than the zero value
+	setNullCalled bool
+}
+
+func (c *customNullable) SetNull() {
+	c.Value = ""
+	c.Valid = false
+	c.setNullCalled = true
+}
+
+func (c *customNullable) SetValue(data string) error {
+	if data == "invalid" {
+		return errors.New("invalid value")
+	}
+	c.Value = data
+	c.Valid = true
+	return nil
+}
+
+type customNullableRecord struct {
+	Name     customNullable `csv:"name"`
+	Required customNullable `csv:"required,required"`
+}
+
+func TestCSVNullable(t *testing.T) {
+	require := testifyrequire.New(t)
+	reader := NewStructuredCSVReader[customNullableRecord](strings.NewReader("name,required\nbob,x\n,x\ninvalid,x\nbob,\n"))
+	record, err := reader.Next()
+	require.NoError(err)
+	require.Equal(customNullable{Value: "bob", Valid: true}, record.Name)
+	record, err = reader.Next()
+	require.NoError(err)
+	require.Equal(customNullable{setNullCalled: true}, record.Name)
+	_, err = reader.Next()
+	require.EqualError(err, "on row 4: column \"name\": invalid value")
+	_, err = reader.Next()
+	require.EqualError(err, "on row 5: column \"required\": required is a required field")
+}
@@ -0,0 +1,65 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+// parallelTestCSV builds a file of simpleCSVRecord rows, badRows are given an invalid an_int
+func parallelTestCSV(rows int, badRows ...int) string {
+	var sb strings.Builder
+	sb.WriteString("a_string,a_float,an_int,a_bool\n")
+	for i := 0; i < rows; i++ {
+		anInt := fmt.Sprint(i)
+		for _, bad := range badRows {
+			if bad == i {
+				anInt = "eleven"
+			}
+		}
+		fmt.Fprintf(&sb, "row %v,%v.5,%v,%v\n", i, i, anInt, i%2 == 0)
+	}
+	return sb.String()
+}
+
+func TestReader_ReadAllParallel(t *testing.T) {
+	t.Run("matches ReadAll", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		data := parallelTestCSV(1000)
+		expected, err := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data)).ReadAll()
+		require.NoError(err)
+		for _, workers := range []int{0, 1, 4} {
+			records, err := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data)).ReadAllParallel(workers)
+			require.NoError(err)
+			require.Equal(expected, records)
+		}
+	})
+	t.Run("first error", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		// Row 800 can be decoded before row 500, the earlier row's error still wins
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(parallelTestCSV(1000, 498, 798)))
+		records, err := reader.ReadAllParallel(8)
+		var decodeErr *DecodeError
+		require.True(errors.As(err, &decodeErr))
+		require.Equal(500, decodeErr.Row)
+		require.Equal("an_int", decodeErr.Column)
+		require.Len(records, 498)
+		require.Equal("row 497", records[497].AString)
+	})
+	t.Run("lenient warnings in order", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(parallelTestCSV(200, 10, 20, 150)))
+		reader.LenientTypes = true
+		records, err := reader.ReadAllParallel(4)
+		require.NoError(err)
+		require.Len(records, 200)
+		var rows []int
+		for _, warning := range reader.Warnings() {
+			rows = append(rows, warning.Row)
+		}
+		require.Equal([]int{12, 22, 152}, rows)
+	})
+}
@@ -0,0 +1,108 @@
+package csv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/weisbartb/rcache"
+	"github.com/weisbartb/stack"
+)
+
+// PolymorphicReader reads a CSV where a discriminator column selects the record type of each row.
+// The header is the union of the columns across every record type,
+// each type only decodes the columns it has a field for and ignores the rest.
+type PolymorphicReader struct {
+	// discriminator is the header of the column that selects the record type
+	discriminator string
+	// types map the discriminator value to the record type
+	types  map[string]reflect.Type
+	reader *csv.Reader
+	// currentRow holds the current row for reporting problematic rows
+	currentRow int
+	// headerRead activates after the header gets parsed the first time
+	headerRead bool
+	// headers contain a list of all header values.
+	headers []string
+	// discriminatorIdx is the position of the discriminator column
+	discriminatorIdx int
+}
+
+// NewPolymorphicReader sets up a new polymorphic reader for a given file handle.
+// types map each value of the discriminator column to the struct type the row decodes into.
+func NewPolymorphicReader(fileHandle io.Reader, discriminator string, types map[string]reflect.Type) *PolymorphicReader {
+	return &PolymorphicReader{
+		discriminator: discriminator,
+		types:         types,
+		reader:        csv.NewReader(newBOMSkippingReader(fileHandle)),
+	}
+}
+
+// readHeader reads the header and locates the discriminator column.
+func (p *PolymorphicReader) readHeader() error {
+	row, err := p.reader.Read()
+	if err != nil {
+		return stack.Wrap(err, "reading csv header")
+	}
+	p.headers = row
+	p.discriminatorIdx = -1
+	for k, v := range row {
+		if v == p.discriminator {
+			p.discriminatorIdx = k
+		}
+	}
+	if p.discriminatorIdx < 0 {
+		return stack.Trace(fmt.Errorf("discriminator column %v was not found in the csv", p.discriminator))
+	}
+	p.headerRead = true
+	p.currentRow++
+	return nil
+}
+
+// Next gets the next record in the file, the value is the struct type registered for the row's discriminator.
+// This returns ErrNoMoreRecords (which wraps io.EOF) as a valid control signal to stop the loop.
+func (p *PolymorphicReader) Next() (any, error) {
+	if !p.headerRead {
+		if err := p.readHeader(); err != nil {
+			return nil, stack.Trace(err)
+		}
+	}
+	row, err := p.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, stack.Trace(ErrNoMoreRecords)
+	} else if err != nil {
+		return nil, stack.Wrap(err, fmt.Sprintf("on row %v", p.currentRow))
+	}
+	p.currentRow++
+	recordType, ok := p.types[row[p.discriminatorIdx]]
+	if !ok {
+		return nil, stack.Trace(fmt.Errorf("on row %v: unknown record type %q", p.currentRow, row[p.discriminatorIdx]))
+	}
+	out := reflect.New(recordType).Elem()
+	// Columns without a field in the record type belong to another record type and are ignored
+	if err := decodeCells(out, fieldCache.GetTypeDataFor(recordType), p.headers, row); err != nil {
+		return nil, stack.Wrap(err, fmt.Sprintf("on row %v", p.currentRow))
+	}
+	return out.Interface(), nil
+}
+
+// decodeCells decodes a row into the fields of out matching the headers, cells without a matching field are ignored.
+func decodeCells(out reflect.Value, instructions *rcache.FieldCache[csvInstruction], headers []string, row []string) error {
+	if err := layoutError(instructions); err != nil {
+		return err
+	}
+	for cellOffset, cell := range row {
+		fieldData := columnField(instructions, headers[cellOffset])
+		if fieldData == nil {
+			continue
+		}
+		val, err := fieldData.InstructionData().GetDecoder()(cell, len(cell) == 0)
+		if err != nil {
+			return err
+		}
+		fieldData.settableOf(out).Set(reflect.ValueOf(val))
+	}
+	return nil
+}
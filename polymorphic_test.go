@@ -0,0 +1,65 @@
+package csv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type clickEvent struct {
+	Type string `csv:"type"`
+	URL  string `csv:"url"`
+}
+
+type purchaseEvent struct {
+	Type   string  `csv:"type"`
+	Amount float64 `csv:"amount,required"`
+}
+
+func TestPolymorphicReader(t *testing.T) {
+	types := map[string]reflect.Type{
+		"click":    reflect.TypeFor[clickEvent](),
+		"purchase": reflect.TypeFor[purchaseEvent](),
+	}
+	t.Run("dispatches", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewPolymorphicReader(strings.NewReader(
+			"type,url,amount\nclick,https://example.com,\npurchase,,12.5\n",
+		), "type", types)
+		var records []any
+		for {
+			record, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(err)
+			records = append(records, record)
+		}
+		require.Equal([]any{
+			clickEvent{Type: "click", URL: "https://example.com"},
+			purchaseEvent{Type: "purchase", Amount: 12.5},
+		}, records)
+	})
+	t.Run("unknown type", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewPolymorphicReader(strings.NewReader("type,url\nscroll,x\n"), "type", types)
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: unknown record type "scroll"`)
+	})
+	t.Run("missing discriminator", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewPolymorphicReader(strings.NewReader("kind,url\nclick,x\n"), "type", types)
+		_, err := reader.Next()
+		require.EqualError(err, "discriminator column type was not found in the csv")
+	})
+	t.Run("decode error", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewPolymorphicReader(strings.NewReader("type,amount\npurchase,\n"), "type", types)
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: amount is a required field")
+	})
+}
@@ -0,0 +1,78 @@
+package csv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// getRangeDecoder wraps a numeric decoder to enforce the min= and max= tag options.
+// Bounds that can't be applied to the field type result in a decoder that always errors.
+func getRangeDecoder(decoder decoderFunction, fieldType reflect.Type, fieldName string, minVal, maxVal string) decoderFunction {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	var check func(val reflect.Value) error
+	var err error
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		check, err = getBoundsCheck(fieldName, minVal, maxVal, func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		}, reflect.Value.Int)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		check, err = getBoundsCheck(fieldName, minVal, maxVal, func(s string) (uint64, error) {
+			return strconv.ParseUint(s, 10, 64)
+		}, reflect.Value.Uint)
+	case reflect.Float32, reflect.Float64:
+		check, err = getBoundsCheck(fieldName, minVal, maxVal, func(s string) (float64, error) {
+			return strconv.ParseFloat(s, 64)
+		}, reflect.Value.Float)
+	default:
+		err = fmt.Errorf("%v can not use min/max with type %v", fieldName, fieldType.Kind())
+	}
+	if err != nil {
+		return func(s string, isNull bool) (any, error) {
+			return nil, err
+		}
+	}
+	return func(s string, isNull bool) (any, error) {
+		val, err := decoder(s, isNull)
+		if err != nil || isNull {
+			// Null values have nothing to check
+			return val, err
+		}
+		if err := check(reflect.ValueOf(val)); err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+}
+
+// getBoundsCheck parses the bounds for a numeric type and returns a function to check a decoded value against them.
+func getBoundsCheck[N int64 | uint64 | float64](fieldName, minVal, maxVal string, parse func(string) (N, error), get func(reflect.Value) N) (func(reflect.Value) error, error) {
+	var lower, upper N
+	var hasLower, hasUpper bool
+	var err error
+	if len(minVal) > 0 {
+		if lower, err = parse(minVal); err != nil {
+			return nil, fmt.Errorf("%v has an invalid min of %q: %w", fieldName, minVal, err)
+		}
+		hasLower = true
+	}
+	if len(maxVal) > 0 {
+		if upper, err = parse(maxVal); err != nil {
+			return nil, fmt.Errorf("%v has an invalid max of %q: %w", fieldName, maxVal, err)
+		}
+		hasUpper = true
+	}
+	return func(val reflect.Value) error {
+		n := get(val)
+		if hasLower && n < lower {
+			return fmt.Errorf("%v value %v is less than the minimum of %v", fieldName, n, lower)
+		}
+		if hasUpper && n > upper {
+			return fmt.Errorf("%v value %v is greater than the maximum of %v", fieldName, n, upper)
+		}
+		return nil
+	}, nil
+}
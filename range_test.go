@@ -0,0 +1,51 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type rangeRecord struct {
+	Age   int     `csv:"age,min=0,max=150"`
+	Count uint8   `csv:"count,max=10"`
+	Score float64 `csv:"score,min=-1.5,max=1.5"`
+}
+
+type badRangeRecord struct {
+	Name string `csv:"name,min=1"`
+}
+
+func TestRangeTags(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		csv  string
+		err  string
+	}{
+		{name: "lower bound", csv: "age,count,score\n0,0,-1.5\n"},
+		{name: "upper bound", csv: "age,count,score\n150,10,1.5\n"},
+		{name: "empty values", csv: "age,count,score\n,,\n"},
+		{name: "int below min", csv: "age\n-1\n", err: "on row 2: column \"age\": age value -1 is less than the minimum of 0"},
+		{name: "int above max", csv: "age\n151\n", err: "on row 2: column \"age\": age value 151 is greater than the maximum of 150"},
+		{name: "uint above max", csv: "count\n11\n", err: "on row 2: column \"count\": count value 11 is greater than the maximum of 10"},
+		{name: "float below min", csv: "score\n-1.6\n", err: "on row 2: column \"score\": score value -1.6 is less than the minimum of -1.5"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require := testifyrequire.New(t)
+			reader := NewStructuredCSVReader[rangeRecord](strings.NewReader(tc.csv))
+			_, err := reader.Next()
+			if len(tc.err) == 0 {
+				require.NoError(err)
+				return
+			}
+			require.EqualError(err, tc.err)
+		})
+	}
+	t.Run("non-numeric field", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[badRangeRecord](strings.NewReader("name\nbob\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"name\": name can not use min/max with type string")
+	})
+}
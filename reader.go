@@ -1,30 +1,149 @@
 package csv
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"maps"
+	"math/big"
 	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/weisbartb/rcache"
 	"github.com/weisbartb/stack"
 )
 
+// ErrNoMoreRecords is returned by Next once every record has been read.
+// It wraps io.EOF, so checking for either with errors.Is works.
+var ErrNoMoreRecords = fmt.Errorf("no more records: %w", io.EOF)
+
+// Warning describes a cell that failed to decode and was skipped rather than stopping the read.
+type Warning struct {
+	// Row is the row the cell was on
+	Row int
+	// Column is the header of the column the cell was in
+	Column string
+	// Value is the raw value of the cell
+	Value string
+	// Err is the decoder error
+	Err error
+}
+
+// String formats the warning for logging.
+func (w Warning) String() string {
+	return fmt.Sprintf("on row %v: column %q: value %q: %v", w.Row, w.Column, w.Value, w.Err)
+}
+
 // Reader holds the state of a CSV reader and binds it to a given record type.
 // Record can be any struct
 type Reader[Record any] struct {
 	// StrictMode will error on any unhandled fields seen in the CSV
 	StrictMode bool
+	// AllowedExtraColumns are headers StrictMode accepts even though the record has no field for them
+	AllowedExtraColumns []string
+	// RecordTerminator sets a custom rune that ends a record in place of a newline.
+	// This is applied when the header is read, see recordTerminatorReader for quoting limitations.
+	RecordTerminator rune
+	// RetryRead is consulted when reading a row fails with an error other than io.EOF.
+	// The read is retried while it returns true, attempt starts at 1.
+	// The underlying csv.Reader is not safely retryable for all errors:
+	// a csv.ParseError has already consumed the malformed record, so a retry moves on to the next one.
+	RetryRead func(err error, attempt int) bool
+	// LenientTypes keeps the zero value for a scalar field that fails to decode instead of erroring.
+	// Every substitution is recorded in Warnings, required fields always error.
+	LenientTypes bool
+	// HeaderNormalize normalizes each header name before it is matched against the record, see normalizeHeader.
+	// This runs before HeaderRewrite.
+	HeaderNormalize bool
+	// HeaderRewrite is called with each header name before it is matched against the record.
+	HeaderRewrite func(header string) string
+	// CaseInsensitiveHeaders matches header names to the record's fields regardless of case, e.g. EMAIL matches email.
+	// Matching headers are replaced with the field's name, so StrictMode and Headers see the same name. This runs after HeaderRewrite.
+	CaseInsensitiveHeaders bool
+	// SkipTrailingBlankLines consumes blank lines at the end of the file, including lines that only hold whitespace,
+	// so Next returns io.EOF rather than a zero valued record. This is on by default.
+	// Blank lines followed by data are still returned, rows of empty cells (e.g. ,,) are data and are always returned.
+	SkipTrailingBlankLines bool
+	// TrimSpace trims leading and trailing whitespace from every cell before decoding, a cell that is only whitespace is null.
+	// This runs before any trim= tag option.
+	TrimSpace bool
+	// NullValues are sentinels such as NULL or \N that are read as a null cell, so required and default= apply as if it was empty.
+	// This adds to any null= tag option on a field.
+	NullValues []string
+	// NullValuesIgnoreCase matches NullValues and null= tag options regardless of case, matching is case-sensitive by default.
+	NullValuesIgnoreCase bool
+	// StripExcelTextForcing unwraps string cells using Excel's ="value" syntax for forcing text, e.g. ="00123" becomes 00123.
+	StripExcelTextForcing bool
+	// AllowThousandsCommas strips commas from int, uint, and float cells before decoding, e.g. "1,234,567" becomes 1234567.
+	AllowThousandsCommas bool
+	// FloatTolerance errors when a float cell can't be represented within this relative difference, e.g. a long decimal read into a float32.
+	// 0 or less disables the check.
+	FloatTolerance float64
+	// InternStrings reuses one instance of each distinct decoded string, cutting memory for columns with few distinct values.
+	// Every distinct value is kept for the life of the reader, so this should not be used on high cardinality columns.
+	InternStrings bool
+	// Profile records the cumulative time spent decoding each column, see ColumnTimings.
+	Profile bool
+	// CommentPrefix skips any data row whose first cell starts with the prefix, such as # or //.
+	// Unlike csv.Reader.Comment this can be multiple characters, comment rows still advance the row counter.
+	CommentPrefix string
+	// MaxColumns caps the number of columns accepted in the header and in every row, 0 is unlimited.
+	// Delimiters are counted as the data streams in, so a row over the limit fails before csv.Reader parses and allocates it.
+	// The rest of that row is dropped, the next call to Next moves on to the following row.
+	MaxColumns int
+	// HeaderRow is the zero-based index of the header row, any rows before it are kept in Preamble.
+	HeaderRow int
+	// NoHeader reads a CSV without a header row, the columns are mapped to the record's fields in the order they are declared.
+	// Every row must have one cell per field (lineno fields excluded), Headers returns the field names.
+	NoHeader bool
+	// source holds the file handle the reader was created with
+	source io.Reader
 	// reader holds the underlying CSV reader
 	reader *csv.Reader
 	// currentRow holds the current row for reporting problematic rows
 	currentRow int
 	// headerRead activates after the header gets parsed the first time
 	headerRead bool
+	// initialized activates once the header has been checked against the record, see initialize
+	initialized bool
 	// headerMap stores the position of all header keys
 	headerMap map[string]int
 	// headers contain a list of all header values.
 	headers []string
+	// columnTimings hold the cumulative decode time for each column when profiling
+	columnTimings map[string]time.Duration
+	// pending holds rows that were read ahead while checking for trailing blank lines
+	pending []pendingRow
+	// locale holds the formatting conventions set by SetLocale
+	locale *Locale
+	// decrypters hold the hooks set by SetCellDecrypter for each column
+	decrypters map[string]func(string) (string, error)
+	// typeHooks hold the post decode hooks for each kind of field
+	typeHooks map[reflect.Kind]func(any) (any, error)
+	// preamble holds the rows read before the header
+	preamble [][]string
+	// warnings contain the decode failures that were skipped by LenientTypes
+	warnings []Warning
+	// ignoredColumns contain the header values that have no matching field in the record
+	ignoredColumns []string
+	// interned holds the canonical instance of each decoded string when InternStrings is on
+	interned map[string]string
+	// decodeMu guards the state decodeRow updates (warnings, timings and interned strings) for ReadAllParallel
+	decodeMu sync.Mutex
+	// lineNoFields hold the fields tagged with lineno
+	lineNoFields []*recordField
+	// extrasField is the field tagged with extras that holds the columns without a field, it is nil if the record has none
+	extrasField *recordField
+	// defaultFields hold the fields with a default= tag whose column is missing from the csv
+	defaultFields []*recordField
 	// instruction holds a cached copy of the record instruction
 	instruction *rcache.FieldCache[csvInstruction]
 }
@@ -33,12 +152,41 @@ func (r *Reader[Record]) readHeader() error {
 	if r.headerRead {
 		return nil
 	}
+	inferFieldCount := r.reader.FieldsPerRecord == 0
+	if r.HeaderRow > 0 && inferFieldCount {
+		// The preamble can be any shape, the field count is inferred from the header instead
+		r.reader.FieldsPerRecord = -1
+	}
+	for len(r.preamble) < r.HeaderRow {
+		row, err := r.reader.Read()
+		if err != nil {
+			return stack.Wrap(err, fmt.Sprintf("reading csv preamble row %v", r.currentRow))
+		}
+		r.preamble = append(r.preamble, slices.Clone(row))
+		r.currentRow++
+	}
+	if r.NoHeader {
+		r.useFieldOrder(inferFieldCount)
+		return nil
+	}
 	row, err := r.reader.Read()
 	if err != nil {
 		return stack.Wrap(err, "reading csv header")
 	}
+	if r.HeaderRow > 0 && inferFieldCount {
+		r.reader.FieldsPerRecord = len(row)
+	}
 	r.headerMap = map[string]int{}
 	for k, v := range row {
+		if r.HeaderNormalize {
+			v = normalizeHeader(v)
+		}
+		if r.HeaderRewrite != nil {
+			v = r.HeaderRewrite(v)
+		}
+		if r.CaseInsensitiveHeaders {
+			v = r.matchHeaderCase(v)
+		}
 		r.headers = append(r.headers, v)
 		r.headerMap[v] = k
 	}
@@ -47,44 +195,327 @@ func (r *Reader[Record]) readHeader() error {
 	return nil
 }
 
-// initialize initializes the reader
+// useFieldOrder sets the headers to the record's fields in declaration order for NoHeader, nothing is read.
+func (r *Reader[Record]) useFieldOrder(inferFieldCount bool) {
+	fields := columnFields(r.instruction)
+	if inferFieldCount {
+		// Rows with a different number of cells can't be mapped by position
+		r.reader.FieldsPerRecord = len(fields)
+	}
+	r.headerMap = map[string]int{}
+	for k, field := range fields {
+		name := field.InstructionData().lookupName()
+		r.headers = append(r.headers, name)
+		r.headerMap[name] = k
+	}
+	r.headerRead = true
+}
+
+// matchHeaderCase returns the name or alias of the record field matching a header regardless of case.
+// Headers without a matching field are returned as-is.
+func (r *Reader[Record]) matchHeaderCase(header string) string {
+	if columnField(r.instruction, header) != nil {
+		return header
+	}
+	for _, field := range columnFields(r.instruction) {
+		instruction := field.InstructionData()
+		for _, name := range append([]string{instruction.GetCSVHeaderIdentifier()}, instruction.GetAliases()...) {
+			if strings.EqualFold(name, header) {
+				return name
+			}
+		}
+	}
+	return header
+}
+
+// normalizeHeader converts a header name to snake_case.
+// Surrounding whitespace is trimmed, camelCase words are split, internal whitespace is collapsed into a single underscore,
+// and everything is lowercased; e.g. " Email Address ", "email_address", and "EmailAddress" all become "email_address".
+func normalizeHeader(header string) string {
+	var words strings.Builder
+	var prev rune
+	for _, c := range strings.TrimSpace(header) {
+		if unicode.IsUpper(c) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			// Split camelCase words
+			words.WriteByte(' ')
+		}
+		words.WriteRune(c)
+		prev = c
+	}
+	return strings.ToLower(strings.Join(strings.Fields(words.String()), "_"))
+}
+
+// stripExcelTextForcing unwraps a cell in Excel's ="value" syntax, any other cell is returned as-is.
+func stripExcelTextForcing(cell string) string {
+	if len(cell) >= 3 && strings.HasPrefix(cell, `="`) && strings.HasSuffix(cell, `"`) {
+		return cell[2 : len(cell)-1]
+	}
+	return cell
+}
+
+// applySourceReaders swaps the underlying CSV reader for one that translates the record terminator and enforces MaxColumns.
+// This must happen before anything is read, the stdlib reader settings are carried over.
+func (r *Reader[Record]) applySourceReaders() {
+	hasTerminator := r.RecordTerminator != 0 && r.RecordTerminator != '\n'
+	if !hasTerminator && r.MaxColumns <= 0 {
+		return
+	}
+	var source io.Reader = newBOMSkippingReader(r.source)
+	if hasTerminator {
+		source = newRecordTerminatorReader(source, r.RecordTerminator)
+	}
+	if r.MaxColumns > 0 {
+		source = newColumnLimitReader(source, r.reader.Comma, r.MaxColumns)
+	}
+	reader := csv.NewReader(source)
+	reader.Comma = r.reader.Comma
+	reader.Comment = r.reader.Comment
+	reader.FieldsPerRecord = r.reader.FieldsPerRecord
+	reader.LazyQuotes = r.reader.LazyQuotes
+	reader.TrimLeadingSpace = r.reader.TrimLeadingSpace
+	reader.ReuseRecord = r.reader.ReuseRecord
+	r.reader = reader
+}
+
+// prepareHeader wraps the source and reads the header, without checking it against the record.
+func (r *Reader[Record]) prepareHeader() error {
+	if r.headerRead {
+		return nil
+	}
+	r.applySourceReaders()
+	return stack.Trace(r.readHeader())
+}
+
+// initialize initializes the reader, reading the header if needed and checking it against the record.
 func (r *Reader[Record]) initialize() error {
-	var t Record
-	err := r.readHeader()
-	if err != nil {
+	if err := layoutError(r.instruction); err != nil {
 		return stack.Trace(err)
 	}
-	tOf := reflect.TypeOf(t)
-	// Warm up cache
-	instructions := fieldCache.GetTypeDataFor(tOf)
-	if r.StrictMode {
-		// StrictMode will error for any field that can't be found in the struct
-		for _, v := range r.headers {
-			if instructions.GetFieldByName(v) == nil {
-				return stack.Trace(fmt.Errorf("%v was seen in the csv but not in the record provided", v))
-			}
+	if err := r.prepareHeader(); err != nil {
+		return stack.Trace(err)
+	}
+	instructions := r.instruction
+	r.extrasField = extrasField(instructions)
+	r.ignoredColumns = nil
+	r.defaultFields = nil
+	for _, v := range r.headers {
+		if columnField(instructions, v) != nil || r.extrasField != nil {
+			// Columns without a field are kept in the extras field if there is one
+			continue
+		}
+		if r.StrictMode && !slices.Contains(r.AllowedExtraColumns, v) {
+			// StrictMode will error for any field that can't be found in the struct
+			return stack.Trace(fmt.Errorf("%v was seen in the csv but not in the record provided", v))
 		}
+		r.ignoredColumns = append(r.ignoredColumns, v)
 	}
+	r.lineNoFields = lineNoFields(instructions)
+	// Required fields must have a column, a missing column would otherwise never be visited when decoding rows
+	for _, field := range columnFields(instructions) {
+		instruction := field.InstructionData()
+		if r.hasColumnFor(instruction) {
+			continue
+		}
+		if instruction.IsRequired() {
+			return stack.Trace(fmt.Errorf("%v is a required column but was not seen in the csv", instruction.GetCSVHeaderIdentifier()))
+		}
+		if _, ok := instruction.GetDefault(); ok {
+			r.defaultFields = append(r.defaultFields, field)
+		}
+	}
+	r.initialized = true
 	return nil
 }
 
+// hasColumnFor checks if the header has a column for a field under its name or any of its aliases.
+func (r *Reader[Record]) hasColumnFor(instruction csvInstruction) bool {
+	if _, ok := r.headerMap[instruction.GetCSVHeaderIdentifier()]; ok {
+		return true
+	}
+	for _, alias := range instruction.GetAliases() {
+		if _, ok := r.headerMap[alias]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers returns the header names as they were seen in the CSV (after any rewriting).
+// This is populated once the header has been read.
+func (r *Reader[Record]) Headers() []string {
+	return slices.Clone(r.headers)
+}
+
+// Header reads the header if it hasn't been already and returns a copy of the header names, without consuming a data row.
+// The names are the same as Headers, this is useful for inspecting a dynamic schema before decoding.
+func (r *Reader[Record]) Header() ([]string, error) {
+	if !r.initialized {
+		if err := r.initialize(); err != nil {
+			return nil, stack.Trace(err)
+		}
+	}
+	return slices.Clone(r.headers), nil
+}
+
+// Preamble returns the rows that came before the header when HeaderRow is set.
+// This is populated once the header has been read.
+func (r *Reader[Record]) Preamble() [][]string {
+	return slices.Clone(r.preamble)
+}
+
+// IgnoredColumns returns the columns that were present in the CSV header but have no matching field in the record.
+// The values in these columns are dropped when decoding, unless the record has an extras field which keeps every such column;
+// this is populated once the header has been read.
+func (r *Reader[Record]) IgnoredColumns() []string {
+	return slices.Clone(r.ignoredColumns)
+}
+
+// RequireColumns checks that the CSV header has every named column, in any order, independent of the record's fields.
+// The header is read if it hasn't been already, the record's own checks such as StrictMode and required columns still run on the first Next.
+func (r *Reader[Record]) RequireColumns(names ...string) error {
+	return stack.Trace(r.requireColumns(names, false))
+}
+
+// RequireExactColumns checks that the CSV header has exactly the named columns, in any order.
+// This is the same as RequireColumns but also errors on any column that was not named.
+func (r *Reader[Record]) RequireExactColumns(names ...string) error {
+	return stack.Trace(r.requireColumns(names, true))
+}
+
+// requireColumns validates the header against a set of column names.
+func (r *Reader[Record]) requireColumns(names []string, exact bool) error {
+	// Only the header is needed, the record's own checks are left to the first read
+	if err := r.prepareHeader(); err != nil {
+		return stack.Trace(err)
+	}
+	var missing []string
+	for _, name := range names {
+		if _, ok := r.headerMap[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return stack.Trace(fmt.Errorf("missing required columns: %v", strings.Join(missing, ", ")))
+	}
+	if !exact {
+		return nil
+	}
+	var extra []string
+	for _, header := range r.headers {
+		if !slices.Contains(names, header) {
+			extra = append(extra, header)
+		}
+	}
+	if len(extra) > 0 {
+		return stack.Trace(fmt.Errorf("unexpected columns: %v", strings.Join(extra, ", ")))
+	}
+	return nil
+}
+
+// pendingRow holds the result of a read that was done ahead of time.
+type pendingRow struct {
+	row []string
+	err error
+}
+
+// readRow reads a row from the underlying reader, retrying per RetryRead.
+func (r *Reader[Record]) readRow() (record []string, err error) {
+	record, err = r.reader.Read()
+	for attempt := 1; err != nil && !errors.Is(err, io.EOF) && r.RetryRead != nil && r.RetryRead(err, attempt); attempt++ {
+		record, err = r.reader.Read()
+	}
+	return
+}
+
+// readAhead reads the next row, looking ahead past blank rows to see if they are trailing when SkipTrailingBlankLines is on.
+func (r *Reader[Record]) readAhead() ([]string, error) {
+	if len(r.pending) > 0 {
+		next := r.pending[0]
+		r.pending = r.pending[1:]
+		return next.row, next.err
+	}
+	record, err := r.readRow()
+	if !r.SkipTrailingBlankLines || !isBlankRow(record, err) {
+		return record, err
+	}
+	lookahead := []pendingRow{{row: slices.Clone(record), err: err}}
+	for {
+		next, nextErr := r.readRow()
+		if errors.Is(nextErr, io.EOF) {
+			// Only blank rows were left
+			return nil, nextErr
+		}
+		lookahead = append(lookahead, pendingRow{row: slices.Clone(next), err: nextErr})
+		if !isBlankRow(next, nextErr) {
+			break
+		}
+	}
+	r.pending = lookahead[1:]
+	return lookahead[0].row, lookahead[0].err
+}
+
+// isBlankRow checks if a row came from a blank line, csv.Reader returns a line that only holds whitespace as a single cell.
+// A blank line with the wrong number of fields is still considered blank.
+func isBlankRow(row []string, err error) bool {
+	if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+		return false
+	}
+	return len(row) == 1 && len(strings.TrimSpace(row[0])) == 0
+}
+
 // nextRow is a helper method to get the next row and wrap errors with the row that failed
 func (r *Reader[Record]) nextRow() (record []string, err error) {
-	record, err = r.reader.Read()
+	record, err = r.readAhead()
+	for r.isCommentRow(record, err) {
+		r.currentRow++
+		record, err = r.readAhead()
+	}
+	if errors.Is(err, errTooManyColumns) {
+		// The rest of the row was dropped, so the row is counted and reading can carry on with the next one
+		r.currentRow++
+		return nil, stack.Wrap(err, fmt.Sprintf("on row %v", r.currentRow))
+	}
 	if err == nil {
 		r.currentRow++
+	} else if errors.Is(err, io.EOF) {
+		err = ErrNoMoreRecords
 	} else {
 		err = stack.Wrap(err, fmt.Sprintf("on row %v", r.currentRow))
 	}
 	return
 }
 
+// isCommentRow checks if a row starts with the CommentPrefix.
+// Comment rows don't need to have the same number of fields as the header.
+func (r *Reader[Record]) isCommentRow(row []string, err error) bool {
+	if len(r.CommentPrefix) == 0 || len(row) == 0 || (err != nil && !errors.Is(err, csv.ErrFieldCount)) {
+		return false
+	}
+	return strings.HasPrefix(row[0], r.CommentPrefix)
+}
+
+// NextRawRow gets the raw cells of the next row in the file without decoding them.
+// The header is read first if needed, and the row counter advances the same as it would with Next.
+// This returns ErrNoMoreRecords (which wraps io.EOF) as a valid control signal to stop the loop.
+func (r *Reader[Record]) NextRawRow() ([]string, error) {
+	if !r.initialized {
+		if err := r.initialize(); err != nil {
+			return nil, stack.Trace(err)
+		}
+	}
+	row, err := r.nextRow()
+	if err != nil {
+		return nil, stack.Trace(err)
+	}
+	return row, nil
+}
+
 // Next gets the next Record in the file.
 // This will return a valid Record or an error.
-// This can return io.EOF which is a valid control signal to stop the loop.
+// This returns ErrNoMoreRecords (which wraps io.EOF) as a valid control signal to stop the loop.
 func (r *Reader[Record]) Next() (Record, error) {
 	var out Record
-	if !r.headerRead {
+	if !r.initialized {
 		if err := r.initialize(); err != nil {
 			return out, stack.Trace(err)
 		}
@@ -94,36 +525,557 @@ func (r *Reader[Record]) Next() (Record, error) {
 	if err != nil {
 		return out, stack.Trace(err)
 	}
-	vOf := reflect.ValueOf(&out)
-	tData := vOf.Elem()
+	out, err = r.decodeRow(row, r.currentRow)
+	return out, stack.Trace(err)
+}
+
+// decodeRow decodes the cells of a row into a Record, rowNumber is used for errors, warnings and lineno fields.
+// This only reads the reader's configuration (apart from the state guarded by decodeMu) so rows can be decoded concurrently.
+func (r *Reader[Record]) decodeRow(row []string, rowNumber int) (Record, error) {
+	var out Record
+	err := r.decodeInto(reflect.ValueOf(&out).Elem(), row, rowNumber)
+	return out, err
+}
+
+// decodeInto decodes the cells of a row into tData, a settable struct of the type the reader's instruction was built from.
+func (r *Reader[Record]) decodeInto(tData reflect.Value, row []string, rowNumber int) error {
+	var err error
+	if len(row) < len(r.headers) {
+		// A short row leaves its trailing columns null so required fields are still checked
+		row = append(slices.Clip(row), make([]string, len(r.headers)-len(row))...)
+	} else if len(row) > len(r.headers) {
+		// Cells past the end of the header have no column to be decoded into
+		row = row[:len(r.headers)]
+	}
+	for _, fieldData := range r.defaultFields {
+		// A null cell decodes to the default
+		val, err := fieldData.InstructionData().GetDecoder()("", true)
+		if err != nil {
+			return r.decodeError(rowNumber, fieldData.InstructionData().lookupName(), fieldData, err)
+		}
+		fieldData.settableOf(tData).Set(reflect.ValueOf(val))
+	}
+	for _, fieldData := range r.lineNoFields {
+		if err := setLineNo(fieldData.settableOf(tData), rowNumber); err != nil {
+			return stack.Wrap(err, fmt.Sprintf("on row %v", rowNumber))
+		}
+	}
 
 	for cellOffset, cell := range row {
-		fieldData := r.instruction.GetFieldByName(r.headers[cellOffset])
+		fieldData := columnField(r.instruction, r.headers[cellOffset])
 		// fieldData is nil if the field is ignored or unrecognized.
 		if fieldData == nil {
+			if r.extrasField != nil {
+				setExtra(r.extrasField.settableOf(tData), r.headers[cellOffset], cell)
+			}
 			continue
 		}
+		field := fieldData.settableOf(tData)
+		if decrypt, ok := r.decrypters[r.headers[cellOffset]]; ok && len(cell) > 0 {
+			if cell, err = decrypt(cell); err != nil {
+				return stack.Wrap(fmt.Errorf("decrypting %v: %w", r.headers[cellOffset], err), fmt.Sprintf("on row %v", rowNumber))
+			}
+		}
+		if r.TrimSpace {
+			cell = strings.TrimSpace(cell)
+		}
+		if r.StripExcelTextForcing && isStringKind(field.Type()) {
+			cell = stripExcelTextForcing(cell)
+		}
+		if r.locale != nil && fieldData.InstructionData().isLocalizable(field.Type()) {
+			cell = r.locale.delocalizeCell(field.Type(), cell)
+		}
+		if r.AllowThousandsCommas && isNumericKind(fieldKind(field.Type())) {
+			cell = strings.ReplaceAll(cell, ",", "")
+		}
+		if r.isNullValue(fieldData, cell) {
+			cell = ""
+		}
 		var isNull bool
 		if len(cell) == 0 {
 			// Set the isNull flag for the decoder
 			isNull = true
 		}
+		var start time.Time
+		if r.Profile {
+			start = time.Now()
+		}
 		val, err := fieldData.InstructionData().GetDecoder()(cell, isNull)
+		if r.Profile {
+			r.decodeMu.Lock()
+			r.columnTimings[r.headers[cellOffset]] += time.Since(start)
+			r.decodeMu.Unlock()
+		}
 		if err != nil {
-			return out, stack.Trace(err)
+			if r.LenientTypes && !fieldData.InstructionData().IsRequired() && isScalarKind(field.Type()) {
+				// Leave the zero value in place and keep going, required fields always error
+				r.decodeMu.Lock()
+				r.warnings = append(r.warnings, Warning{
+					Row:    rowNumber,
+					Column: r.headers[cellOffset],
+					Value:  cell,
+					Err:    err,
+				})
+				r.decodeMu.Unlock()
+				continue
+			}
+			return r.decodeError(rowNumber, r.headers[cellOffset], fieldData, err)
+		}
+		if r.FloatTolerance > 0 && !isNull {
+			if err := checkFloatTolerance(cell, val, r.FloatTolerance); err != nil {
+				return r.decodeError(rowNumber, r.headers[cellOffset], fieldData, err)
+			}
+		}
+		if hook, ok := r.typeHooks[field.Type().Kind()]; ok {
+			if val, err = hook(val); err != nil {
+				return r.decodeError(rowNumber, r.headers[cellOffset], fieldData, err)
+			}
+			if vOf := reflect.ValueOf(val); !vOf.IsValid() || !vOf.Type().AssignableTo(field.Type()) {
+				return stack.Trace(fmt.Errorf("on row %v: type hook returned %T for %v", rowNumber, val, r.headers[cellOffset]))
+			}
+		}
+		if r.InternStrings {
+			val = r.intern(val)
 		}
 		// Set the value on the field
-		tData.Field(fieldData.Idx).Set(reflect.ValueOf(val))
+		field.Set(reflect.ValueOf(val))
+		if fieldData.InstructionData().IsNested() {
+			if err := setNestedLineNo(field, rowNumber); err != nil {
+				return stack.Wrap(err, fmt.Sprintf("on row %v", rowNumber))
+			}
+		}
+	}
+	return nil
+}
+
+// isNullValue checks if a cell is one of the NullValues or the field's null= tag option values.
+func (r *Reader[Record]) isNullValue(field *recordField, cell string) bool {
+	if len(cell) == 0 {
+		return false
+	}
+	for _, sentinels := range [][]string{r.NullValues, field.InstructionData().GetNullValues()} {
+		for _, sentinel := range sentinels {
+			if cell == sentinel || r.NullValuesIgnoreCase && strings.EqualFold(cell, sentinel) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeError wraps an error decoding a cell of the current row in a DecodeError.
+func (r *Reader[Record]) decodeError(rowNumber int, column string, field *recordField, err error) error {
+	return stack.Trace(&DecodeError{
+		Row:    rowNumber,
+		Column: column,
+		Field:  field.InstructionData().structFieldName,
+		Err:    err,
+	})
+}
+
+// NextContext is Next but checks the context before reading the row, so a long read can be cancelled between rows.
+// The context's error is returned wrapped with the row that was about to be read.
+func (r *Reader[Record]) NextContext(ctx context.Context) (Record, error) {
+	if err := ctx.Err(); err != nil {
+		var out Record
+		return out, stack.Wrap(err, fmt.Sprintf("on row %v", r.currentRow+1))
+	}
+	return r.Next()
+}
+
+// readAllInitialCapacity is the number of records ReadAll allocates room for up front
+const readAllInitialCapacity = 128
+
+// ReadAll decodes every remaining record in the file.
+// On an error the records decoded so far are returned along with it, reaching the end of the file is not an error.
+func (r *Reader[Record]) ReadAll() ([]Record, error) {
+	records, err := r.ReadAllContext(context.Background())
+	return records, stack.Trace(err)
+}
+
+// ReadAllContext is ReadAll but stops with the context's error once it is done, see NextContext.
+func (r *Reader[Record]) ReadAllContext(ctx context.Context) ([]Record, error) {
+	records := make([]Record, 0, readAllInitialCapacity)
+	for {
+		record, err := r.NextContext(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return records, stack.Trace(err)
+		}
+		records = append(records, record)
+	}
+}
+
+// ReadAllParallel is ReadAll but decodes the rows on a number of worker goroutines, the records are still returned in file order.
+// Rows are read on a single goroutine since the underlying csv reader can't be shared, so this helps when decoding is the bottleneck
+// (e.g. decrypters, type hooks or many columns) rather than reading.
+// A workers value less than 1 uses runtime.GOMAXPROCS(0).
+// On an error the records before the first failing row are returned alongside the error for that row.
+// Type hooks, decrypters and custom decoders are called concurrently so they must be safe for concurrent use.
+func (r *Reader[Record]) ReadAllParallel(workers int) ([]Record, error) {
+	if !r.initialized {
+		if err := r.initialize(); err != nil {
+			return nil, stack.Trace(err)
+		}
+	}
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	type job struct {
+		index     int
+		row       []string
+		rowNumber int
+	}
+	type result struct {
+		index  int
+		record Record
+		err    error
+	}
+	jobs := make(chan job, workers*2)
+	results := make(chan result, workers*2)
+	stop := make(chan struct{})
+	var readErr error
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			row, err := r.nextRow()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					readErr = err
+				}
+				return
+			}
+			select {
+			// The row is cloned in case ReuseRecord is set
+			case jobs <- job{index: index, row: slices.Clone(row), rowNumber: r.currentRow}:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				record, err := r.decodeRow(j.row, j.rowNumber)
+				results <- result{index: j.index, record: record, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	records := make([]Record, 0, readAllInitialCapacity)
+	// Records that finished ahead of an earlier row wait here until they're next
+	pending := map[int]Record{}
+	firstErrIndex := -1
+	var firstErr error
+	for res := range results {
+		if res.err != nil {
+			if firstErr == nil {
+				close(stop)
+			}
+			if firstErr == nil || res.index < firstErrIndex {
+				firstErr, firstErrIndex = res.err, res.index
+			}
+			continue
+		}
+		pending[res.index] = res.record
+		for {
+			record, ok := pending[len(records)]
+			if !ok {
+				break
+			}
+			delete(pending, len(records))
+			records = append(records, record)
+		}
+	}
+	// Warnings are appended as the workers finish, put them back in row order
+	slices.SortStableFunc(r.warnings, func(a, b Warning) int {
+		return a.Row - b.Row
+	})
+	if firstErr != nil {
+		return records, stack.Trace(firstErr)
+	}
+	if readErr != nil {
+		return records, stack.Trace(readErr)
+	}
+	return records, nil
+}
+
+// Column reads the rest of the file and returns the raw cells of a single column by header name, other columns are not decoded.
+func (r *Reader[Record]) Column(name string) ([]string, error) {
+	if !r.initialized {
+		if err := r.initialize(); err != nil {
+			return nil, stack.Trace(err)
+		}
+	}
+	idx, ok := r.headerMap[name]
+	if !ok {
+		return nil, stack.Trace(fmt.Errorf("column %v was not found in the csv", name))
+	}
+	var cells []string
+	for {
+		row, err := r.nextRow()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return cells, nil
+			}
+			return cells, stack.Trace(err)
+		}
+		if idx >= len(row) {
+			// The row is short when AllowVariableColumns is on
+			cells = append(cells, "")
+			continue
+		}
+		cells = append(cells, row[idx])
+	}
+}
+
+// All returns an iterator over the remaining records for use with range.
+// Each record is yielded with a nil error, the end of the file stops the iteration without yielding,
+// and any other error is yielded once with a zero valued record before stopping.
+// Breaking out of the loop leaves the reader positioned after the last record yielded.
+func (r *Reader[Record]) All() iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		for {
+			record, err := r.Next()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(record, err)
+				}
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// SetLocale applies the formatting conventions of a locale, such as LocaleDE, to decoding numbers, booleans, and dates.
+// This also sets the delimiter if the locale has one, so it must be called before the header is read.
+func (r *Reader[Record]) SetLocale(locale Locale) {
+	r.locale = &locale
+	if locale.Delimiter != 0 {
+		r.reader.Comma = locale.Delimiter
+	}
+}
+
+// SetDelimiter sets the field delimiter, such as ';' or '\t'.
+// This must be called before the header is read.
+func (r *Reader[Record]) SetDelimiter(delimiter rune) error {
+	if r.headerRead {
+		return stack.Trace(errors.New("the delimiter can not be changed after the header has been read"))
+	}
+	r.reader.Comma = delimiter
+	return nil
+}
+
+// AllowVariableColumns lets rows have a different number of cells than the header instead of erroring.
+// The trailing columns of a short row are null and the cells past the end of the header in a long row are ignored.
+// Turning it back off requires every following row to match the header again.
+func (r *Reader[Record]) AllowVariableColumns(allow bool) {
+	switch {
+	case allow:
+		r.reader.FieldsPerRecord = -1
+	case r.headerRead:
+		r.reader.FieldsPerRecord = len(r.headers)
+	default:
+		// The field count is inferred from the header
+		r.reader.FieldsPerRecord = 0
+	}
+}
+
+// Reset rebinds the reader to a new source so the next read starts with its header, keeping the options and cached field data.
+// The header, row counter, preamble, warnings, and column timings are cleared, the delimiter and other csv.Reader settings are carried over.
+func (r *Reader[Record]) Reset(source io.Reader) {
+	reader := csv.NewReader(newBOMSkippingReader(source))
+	reader.Comma = r.reader.Comma
+	reader.Comment = r.reader.Comment
+	reader.LazyQuotes = r.reader.LazyQuotes
+	reader.TrimLeadingSpace = r.reader.TrimLeadingSpace
+	reader.ReuseRecord = r.reader.ReuseRecord
+	if r.reader.FieldsPerRecord < 0 {
+		// A positive count was inferred from the last header, the new source gets its own
+		reader.FieldsPerRecord = r.reader.FieldsPerRecord
+	}
+	r.source = source
+	r.reader = reader
+	r.currentRow = 0
+	r.headerRead = false
+	r.initialized = false
+	r.headerMap = nil
+	r.headers = nil
+	r.pending = nil
+	r.preamble = nil
+	r.warnings = nil
+	r.ignoredColumns = nil
+	r.lineNoFields = nil
+	r.extrasField = nil
+	r.defaultFields = nil
+	r.columnTimings = map[string]time.Duration{}
+}
+
+// SetComment sets a rune that marks a line as a comment when it is the first character, these lines are skipped.
+// Comment lines before the header are skipped as well, see CommentPrefix for multi-character markers.
+// This must be called before the header is read.
+func (r *Reader[Record]) SetComment(comment rune) error {
+	if r.headerRead {
+		return stack.Trace(errors.New("the comment can not be changed after the header has been read"))
+	}
+	r.reader.Comment = comment
+	return nil
+}
+
+// SetLazyQuotes allows bare quotes in unquoted fields and non-doubled quotes in quoted fields, see csv.Reader.LazyQuotes.
+// This applies from the next row read, so call it before the header is read if the header needs it too.
+func (r *Reader[Record]) SetLazyQuotes(lazy bool) {
+	r.reader.LazyQuotes = lazy
+}
+
+// SetCellDecrypter sets a hook that decrypts the cells of a column before they are decoded, see Writer.SetCellEncrypter.
+// Null cells are not passed to the hook.
+func (r *Reader[Record]) SetCellDecrypter(column string, fn func(string) (string, error)) {
+	if r.decrypters == nil {
+		r.decrypters = map[string]func(string) (string, error){}
+	}
+	r.decrypters[column] = fn
+}
+
+// SetTypeHook registers a hook that runs on every decoded value for fields of the given kind before it is set.
+// The kind is the field's own kind, so a hook for reflect.Ptr receives the decoded pointer of every pointer field.
+// The hook must return a value assignable to the field, e.g. strings.TrimSpace for every string field.
+func (r *Reader[Record]) SetTypeHook(kind reflect.Kind, fn func(any) (any, error)) {
+	if r.typeHooks == nil {
+		r.typeHooks = map[reflect.Kind]func(any) (any, error){}
+	}
+	r.typeHooks[kind] = fn
+}
+
+// intern returns the canonical instance of a decoded string, other values are returned as-is.
+// csv.Reader slices every cell of a row out of one string, so interning also stops a single value from keeping its whole row in memory.
+func (r *Reader[Record]) intern(val any) any {
+	rv := reflect.ValueOf(val)
+	if !rv.IsValid() || rv.Kind() != reflect.String {
+		return val
+	}
+	s := rv.String()
+	r.decodeMu.Lock()
+	defer r.decodeMu.Unlock()
+	if r.interned == nil {
+		r.interned = map[string]string{}
+	}
+	canonical, ok := r.interned[s]
+	if !ok {
+		canonical = strings.Clone(s)
+		r.interned[canonical] = canonical
+	}
+	if rv.Type() == tOfString {
+		return canonical
+	}
+	// Named string types need to be converted back to the field type
+	return reflect.ValueOf(canonical).Convert(rv.Type()).Interface()
+}
+
+// checkFloatTolerance compares a decoded float to the exact decimal value of the cell it came from.
+// Values that are not floats are ignored.
+func checkFloatTolerance(cell string, val any, tolerance float64) error {
+	decoded := reflect.Indirect(reflect.ValueOf(val))
+	if !decoded.IsValid() || (decoded.Kind() != reflect.Float32 && decoded.Kind() != reflect.Float64) {
+		return nil
+	}
+	exact, ok := new(big.Float).SetPrec(256).SetString(cell)
+	if !ok || exact.Sign() == 0 {
+		return nil
+	}
+	diff := new(big.Float).SetPrec(256).SetFloat64(decoded.Float())
+	diff.Sub(diff, exact).Quo(diff, exact)
+	relative, _ := diff.Abs(diff).Float64()
+	if relative > tolerance {
+		return fmt.Errorf("%v loses precision as %v (relative difference %g exceeds %g)", cell, decoded.Float(), relative, tolerance)
+	}
+	return nil
+}
+
+// isNumericKind checks if a kind is an int, uint, or float.
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldKind gets the kind of a field, dereferencing pointers.
+func fieldKind(t reflect.Type) reflect.Kind {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Kind()
+	}
+	return t.Kind()
+}
+
+// Row returns the row number of the last row read, using the same numbering as errors and warnings.
+// The header is row 1 so the first record is row 2, preamble and comment rows are counted as well.
+// This is 0 until the header has been read.
+func (r *Reader[Record]) Row() int {
+	return r.currentRow
+}
+
+// ColumnTimings returns the cumulative time spent decoding each column while Profile was on.
+func (r *Reader[Record]) ColumnTimings() map[string]time.Duration {
+	return maps.Clone(r.columnTimings)
+}
+
+// Warnings returns every cell that could not be decoded and was replaced with a zero value by LenientTypes.
+func (r *Reader[Record]) Warnings() []Warning {
+	return slices.Clone(r.warnings)
+}
+
+// DetectDuplicates reads the rest of the file and returns the row numbers of records whose key was already seen.
+// The key function should combine every column that makes up the composite unique key.
+func (r *Reader[Record]) DetectDuplicates(key func(Record) string) ([]int, error) {
+	var duplicates []int
+	seen := map[string]struct{}{}
+	for {
+		record, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return duplicates, nil
+			}
+			return duplicates, stack.Trace(err)
+		}
+		k := key(record)
+		if _, ok := seen[k]; ok {
+			duplicates = append(duplicates, r.currentRow)
+			continue
+		}
+		seen[k] = struct{}{}
 	}
-	return out, nil
 }
 
 // NewStructuredCSVReader sets up a new reader for a given file handle.
 func NewStructuredCSVReader[Record any](fileHandle io.Reader) *Reader[Record] {
 	var T Record
-	wrapper := &Reader[Record]{
-		reader:      csv.NewReader(fileHandle),
-		instruction: fieldCache.GetTypeDataFor(reflect.TypeOf(T)),
+	return newReader[Record](fileHandle, fieldCache.GetTypeDataFor(reflect.TypeOf(T)))
+}
+
+// newReader sets up a reader that decodes rows into the struct the instruction was built from.
+// This lets a reader be made for a type only known at runtime (see nested), the Record type is then only a placeholder.
+func newReader[Record any](fileHandle io.Reader, instruction *rcache.FieldCache[csvInstruction]) *Reader[Record] {
+	return &Reader[Record]{
+		SkipTrailingBlankLines: true,
+		columnTimings:          map[string]time.Duration{},
+		source:                 fileHandle,
+		reader:                 csv.NewReader(newBOMSkippingReader(fileHandle)),
+		instruction:            instruction,
 	}
-	return wrapper
 }
@@ -1,8 +1,19 @@
 package csv
 
 import (
+	"bytes"
+	"context"
 	"embed"
+	"encoding/csv"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"unsafe"
 
 	testifyrequire "github.com/stretchr/testify/require"
 )
@@ -80,7 +91,1073 @@ func TestReader_Next(t *testing.T) {
 
 		_, _ = reader.Next()
 		_, err = reader.Next()
-		require.EqualError(err, "an_int is a required field")
+		require.EqualError(err, "on row 3: column \"an_int\": an_int is a required field")
 	})
 
 }
+
+func TestReader_RecordTerminator(t *testing.T) {
+	require := testifyrequire.New(t)
+	fh, err := testData.Open("testdata/tilde-terminated.csv")
+	require.NoError(err)
+	reader := NewStructuredCSVReader[simpleCSVRecord](fh)
+	reader.RecordTerminator = '~'
+	record, err := reader.Next()
+	require.NoError(err)
+	require.Equal(11, record.AnInt)
+	require.Equal("string~with tilde", record.AString)
+	require.Equal(523.52, record.AFloat)
+	require.Equal(true, record.ABool)
+	record, err = reader.Next()
+	require.NoError(err)
+	require.Equal(12, record.AnInt)
+	require.Equal("multi\nline", record.AString)
+	require.Equal(false, record.ABool)
+	_, err = reader.Next()
+	require.ErrorIs(err, io.EOF)
+}
+
+func TestReader_IgnoredColumns(t *testing.T) {
+	t.Run("ignored column", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		fh, err := testData.Open("testdata/simple.csv")
+		require.NoError(err)
+		reader := NewStructuredCSVReader[simpleCSVRecordStrictFail](fh)
+		require.Empty(reader.IgnoredColumns())
+		_, err = reader.Next()
+		require.NoError(err)
+		require.Equal([]string{"a_bool"}, reader.IgnoredColumns())
+	})
+	t.Run("all columns mapped", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		fh, err := testData.Open("testdata/simple.csv")
+		require.NoError(err)
+		reader := NewStructuredCSVReader[simpleCSVRecord](fh)
+		_, err = reader.Next()
+		require.NoError(err)
+		require.Empty(reader.IgnoredColumns())
+	})
+}
+
+func TestReader_NextRawRow(t *testing.T) {
+	require := testifyrequire.New(t)
+	fh, err := testData.Open("testdata/simple-required.csv")
+	require.NoError(err)
+	reader := NewStructuredCSVReader[simpleCSVRecord](fh)
+	row, err := reader.NextRawRow()
+	require.NoError(err)
+	require.Equal([]string{"11", "string", "523.52", ""}, row)
+	require.Equal(2, reader.currentRow)
+	record, err := reader.Next()
+	require.NoError(err)
+	require.Equal(false, record.ABool)
+	require.Equal(3, reader.currentRow)
+	_, err = reader.NextRawRow()
+	require.ErrorIs(err, io.EOF)
+}
+
+// flakyReader returns each chunk from a separate read, erroring on any chunk that is a transient failure.
+type flakyReader struct {
+	chunks []string
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	if len(f.chunks) == 0 {
+		return 0, io.EOF
+	}
+	chunk := f.chunks[0]
+	f.chunks = f.chunks[1:]
+	if chunk == "!" {
+		return 0, errors.New("connection reset")
+	}
+	return copy(p, chunk), nil
+}
+
+func TestReader_RetryRead(t *testing.T) {
+	t.Run("recovers", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](&flakyReader{
+			chunks: []string{"an_int,a_string\n", "!", "!", "11,string\n"},
+		})
+		var attempts []int
+		reader.RetryRead = func(err error, attempt int) bool {
+			attempts = append(attempts, attempt)
+			return true
+		}
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(11, record.AnInt)
+		require.Equal(2, reader.currentRow)
+		require.Equal([]int{1, 2}, attempts)
+	})
+	t.Run("gives up", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](&flakyReader{
+			chunks: []string{"an_int,a_string\n", "!", "11,string\n"},
+		})
+		reader.RetryRead = func(err error, attempt int) bool {
+			return false
+		}
+		_, err := reader.Next()
+		require.EqualError(err, "on row 1: connection reset")
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(11, record.AnInt)
+	})
+}
+
+func TestReader_DetectDuplicates(t *testing.T) {
+	require := testifyrequire.New(t)
+	reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(
+		"a_string,an_int,a_float\na,1,1.5\nb,1,1.5\na,1,2.5\na,2,1.5\nb,1,3\n",
+	))
+	duplicates, err := reader.DetectDuplicates(func(record simpleCSVRecord) string {
+		return record.AString + "|" + strconv.Itoa(record.AnInt)
+	})
+	require.NoError(err)
+	require.Equal([]int{4, 6}, duplicates)
+}
+
+func TestReader_LenientTypes(t *testing.T) {
+	t.Run("substitutes zero values", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(
+			"a_string,an_int,a_float,a_bool\na,x,1.5,true\nb,2,y,maybe\n",
+		))
+		reader.LenientTypes = true
+		records, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal([]simpleCSVRecord{
+			{AString: "a", AFloat: 1.5, ABool: true},
+			{AString: "b", AnInt: 2},
+		}, records)
+		warnings := reader.Warnings()
+		require.Len(warnings, 3)
+		require.Equal(2, warnings[0].Row)
+		require.Equal("an_int", warnings[0].Column)
+		require.Equal("x", warnings[0].Value)
+		require.Equal(`on row 3: column "a_bool": value "maybe": strconv.ParseBool: parsing "maybe": invalid syntax`, warnings[2].String())
+	})
+	t.Run("required still errors", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		fh, err := testData.Open("testdata/simple-required.csv")
+		require.NoError(err)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](fh)
+		reader.LenientTypes = true
+		_, err = readAllRecords(reader)
+		require.EqualError(err, "on row 3: column \"an_int\": an_int is a required field")
+	})
+	t.Run("required with a bad value still errors", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](strings.NewReader("a_string,a_float,an_int\na,1.5,x\n"))
+		reader.LenientTypes = true
+		_, err := reader.Next()
+		require.ErrorContains(err, `on row 2: column "an_int": strconv.ParseInt: parsing "x": invalid syntax`)
+		require.Empty(reader.Warnings())
+	})
+	t.Run("off by default", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("an_int\nx\n"))
+		_, err := reader.Next()
+		require.Error(err)
+		require.Empty(reader.Warnings())
+	})
+}
+
+// readAllRecords reads every record until io.EOF.
+func readAllRecords[Record any](reader *Reader[Record]) ([]Record, error) {
+	var out []Record
+	for {
+		record, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return out, nil
+			}
+			return out, err
+		}
+		out = append(out, record)
+	}
+}
+
+func TestReader_HeaderNormalize(t *testing.T) {
+	t.Run("normalize", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		for _, header := range []string{" Email Address ", "email_address", "EmailAddress", "EMAIL   ADDRESS", "Email\tAddress"} {
+			require.Equal("email_address", normalizeHeader(header), header)
+		}
+	})
+	t.Run("reader", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(
+			" A String ,AnInt,A_FLOAT,aBool\nstring,11,523.52,true\n",
+		))
+		reader.HeaderNormalize = true
+		reader.StrictMode = true
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "string", AnInt: 11, AFloat: 523.52, ABool: true}, record)
+	})
+	t.Run("composes with rewrite", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("String,Int\nstring,11\n"))
+		reader.HeaderNormalize = true
+		reader.HeaderRewrite = func(header string) string {
+			if header == "int" {
+				return "an_int"
+			}
+			return "a_" + header
+		}
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "string", AnInt: 11}, record)
+	})
+}
+
+func TestReader_HeaderRow(t *testing.T) {
+	require := testifyrequire.New(t)
+	fh, err := testData.Open("testdata/preamble.csv")
+	require.NoError(err)
+	reader := NewStructuredCSVReader[simpleCSVRecord](fh)
+	// The blank line is skipped by the csv reader, so the header is the third row
+	reader.HeaderRow = 2
+	record, err := reader.Next()
+	require.NoError(err)
+	require.Equal(simpleCSVRecord{AString: "string", AnInt: 11, AFloat: 523.52, ABool: true}, record)
+	require.Equal([][]string{{"Report", "Monthly Sales"}, {"Generated", "2024-01-01", "by ops"}}, reader.Preamble())
+	require.Equal(4, reader.currentRow)
+	_, err = reader.Next()
+	require.ErrorIs(err, io.EOF)
+}
+
+func TestReader_SetTypeHook(t *testing.T) {
+	t.Run("normalizes values", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\n  padded  ,5\n"))
+		reader.SetTypeHook(reflect.String, func(val any) (any, error) {
+			return strings.TrimSpace(val.(string)), nil
+		})
+		reader.SetTypeHook(reflect.Int, func(val any) (any, error) {
+			return val.(int) * 2, nil
+		})
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal("padded", record.AString)
+		require.Equal(10, record.AnInt)
+	})
+	t.Run("hook error", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string\nbad\n"))
+		reader.SetTypeHook(reflect.String, func(val any) (any, error) {
+			return nil, errors.New("rejected")
+		})
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"a_string\": rejected")
+	})
+	t.Run("wrong type", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("an_int\n1\n"))
+		reader.SetTypeHook(reflect.Int, func(val any) (any, error) {
+			return "1", nil
+		})
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: type hook returned string for an_int")
+	})
+	t.Run("pointer field", func(t *testing.T) {
+		type pointerHookRecord struct {
+			Elapsed *time.Duration `csv:"elapsed,duration=iso8601"`
+			Count   int64          `csv:"count"`
+		}
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[pointerHookRecord](strings.NewReader("elapsed,count\nPT1H,2\n"))
+		// The int64 hook only sees the int64 field, the pointer goes to the reflect.Ptr hook
+		reader.SetTypeHook(reflect.Int64, func(val any) (any, error) {
+			return val.(int64) * 2, nil
+		})
+		reader.SetTypeHook(reflect.Ptr, func(val any) (any, error) {
+			doubled := *val.(*time.Duration) * 2
+			return &doubled, nil
+		})
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(2*time.Hour, *record.Elapsed)
+		require.Equal(int64(4), record.Count)
+	})
+}
+
+func TestReader_SkipTrailingBlankLines(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		fh, err := testData.Open("testdata/trailing-blank.csv")
+		require.NoError(err)
+		reader := NewStructuredCSVReader[simpleCSVRecord](fh)
+		records, err := readAllRecords(reader)
+		require.NoError(err)
+		// The blank row between records is kept
+		require.Equal([]simpleCSVRecord{
+			{AString: "string", AnInt: 11, AFloat: 523.52, ABool: true},
+			{},
+			{AString: "other", AnInt: 12, AFloat: 1.5},
+		}, records)
+		require.Equal(4, reader.currentRow)
+	})
+	t.Run("trailing empty cells are kept", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("an_int,a_string\n11,a\n,\n,\n\n  \n"))
+		records, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal([]simpleCSVRecord{{AString: "a", AnInt: 11}, {}, {}}, records)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		fh, err := testData.Open("testdata/trailing-blank.csv")
+		require.NoError(err)
+		reader := NewStructuredCSVReader[simpleCSVRecord](fh)
+		reader.SkipTrailingBlankLines = false
+		_, err = readAllRecords(reader)
+		require.ErrorIs(err, csv.ErrFieldCount)
+	})
+}
+
+func TestReader_AllowedExtraColumns(t *testing.T) {
+	t.Run("allowed", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("_internal_id,an_int\n1,11\n"))
+		reader.StrictMode = true
+		reader.AllowedExtraColumns = []string{"_internal_id"}
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(11, record.AnInt)
+		require.Equal([]string{"_internal_id"}, reader.IgnoredColumns())
+	})
+	t.Run("not allowed", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("_internal_id,surprise,an_int\n1,2,11\n"))
+		reader.StrictMode = true
+		reader.AllowedExtraColumns = []string{"_internal_id"}
+		_, err := reader.Next()
+		require.EqualError(err, "surprise was seen in the csv but not in the record provided")
+	})
+}
+
+func TestReader_Profile(t *testing.T) {
+	t.Run("enabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		fh, err := testData.Open("testdata/simple.csv")
+		require.NoError(err)
+		reader := NewStructuredCSVReader[simpleCSVRecordStrictFail](fh)
+		reader.Profile = true
+		_, err = readAllRecords(reader)
+		require.NoError(err)
+		timings := reader.ColumnTimings()
+		// a_bool has no field so it is never decoded
+		require.Len(timings, 3)
+		for _, column := range []string{"an_int", "a_string", "a_float"} {
+			require.Contains(timings, column)
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		fh, err := testData.Open("testdata/simple.csv")
+		require.NoError(err)
+		reader := NewStructuredCSVReader[simpleCSVRecord](fh)
+		_, err = readAllRecords(reader)
+		require.NoError(err)
+		require.Empty(reader.ColumnTimings())
+	})
+}
+
+func TestReader_StripExcelTextForcing(t *testing.T) {
+	require := testifyrequire.New(t)
+	fh, err := testData.Open("testdata/excel-text.csv")
+	require.NoError(err)
+	reader := NewStructuredCSVReader[simpleCSVRecord](fh)
+	reader.StripExcelTextForcing = true
+	// Excel also writes the wrapper unquoted, which needs lazy quotes to parse
+	reader.reader.LazyQuotes = true
+	records, err := readAllRecords(reader)
+	require.NoError(err)
+	var values []string
+	for _, record := range records {
+		values = append(values, record.AString)
+	}
+	require.Equal([]string{"00123", "plain", "", "bare"}, values)
+}
+
+func TestReader_CommentPrefix(t *testing.T) {
+	t.Run("skips comments", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(
+			"a_string,an_int\n// first batch\nstring,11\n// second batch, with a comma\n//,\nother,12\n",
+		))
+		reader.CommentPrefix = "//"
+		records, err := readAllRecords(reader)
+		require.NoError(err)
+		require.Equal([]simpleCSVRecord{
+			{AString: "string", AnInt: 11},
+			{AString: "other", AnInt: 12},
+		}, records)
+		require.Equal(6, reader.currentRow)
+	})
+	t.Run("errors keep the row", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\n# note\nstring,eleven\n"))
+		reader.CommentPrefix = "#"
+		_, err := reader.Next()
+		require.ErrorContains(err, "on row 3:")
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\n#note,1\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal("#note", record.AString)
+	})
+}
+
+func TestReader_Required(t *testing.T) {
+	t.Run("present and filled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](strings.NewReader("a_string,a_float,an_int\nstring,1.5,11\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(requiredCSVRecordStrictFail{AString: "string", AFloat: 1.5, AnInt: 11}, record)
+	})
+	t.Run("present but empty", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](strings.NewReader("a_string,a_float,an_int\nstring,1.5,\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"an_int\": an_int is a required field")
+	})
+	t.Run("absent column", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](strings.NewReader("a_string,a_float\nstring,1.5\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "an_int is a required column but was not seen in the csv")
+	})
+}
+
+func TestAnonymousStructRecords(t *testing.T) {
+	require := testifyrequire.New(t)
+	// These have the same layout but different tags, so they are distinct types and must not share cached instructions
+	type nameRecord = struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+	}
+	type labelRecord = struct {
+		Name string `csv:"label"`
+		Age  int    `csv:"years"`
+	}
+	buf := bytes.Buffer{}
+	writer := NewWriter[nameRecord](&buf)
+	require.NoError(writer.WriteRecord(nameRecord{Name: "alice", Age: 30}))
+	require.NoError(writer.Flush())
+	require.Equal("name,age\nalice,30\n", buf.String())
+	reader := NewStructuredCSVReader[nameRecord](&buf)
+	record, err := reader.Next()
+	require.NoError(err)
+	require.Equal(nameRecord{Name: "alice", Age: 30}, record)
+
+	buf.Reset()
+	labelWriter := NewWriter[labelRecord](&buf)
+	require.NoError(labelWriter.WriteRecord(labelRecord{Name: "bob", Age: 40}))
+	require.NoError(labelWriter.Flush())
+	require.Equal("label,years\nbob,40\n", buf.String())
+	labelReader := NewStructuredCSVReader[labelRecord](&buf)
+	labelRecordOut, err := labelReader.Next()
+	require.NoError(err)
+	require.Equal(labelRecord{Name: "bob", Age: 40}, labelRecordOut)
+}
+
+func TestReader_MaxColumns(t *testing.T) {
+	t.Run("within the limit", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\nstring,11\n"))
+		reader.MaxColumns = 2
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "string", AnInt: 11}, record)
+	})
+	t.Run("wide header", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int,a_bool\nstring,11,true\n"))
+		reader.MaxColumns = 2
+		_, err := reader.Next()
+		require.EqualError(err, "reading csv header: too many columns, the maximum is 2")
+	})
+	t.Run("wide row", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(
+			"a_string,an_int\nstring,11\nstring,12" + strings.Repeat(",", 10000) + "\n",
+		))
+		reader.MaxColumns = 100
+		_, err := reader.Next()
+		require.NoError(err)
+		_, err = reader.Next()
+		require.EqualError(err, "on row 3: too many columns, the maximum is 100")
+	})
+	t.Run("carries on after a wide row", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(
+			"a_string,an_int\n\"a,b\",1\nwide" + strings.Repeat(",", 10) + "\nlast,3\n",
+		))
+		reader.MaxColumns = 2
+		record, err := reader.Next()
+		require.NoError(err)
+		// Delimiters inside of quotes aren't counted
+		require.Equal(simpleCSVRecord{AString: "a,b", AnInt: 1}, record)
+		_, err = reader.Next()
+		require.EqualError(err, "on row 3: too many columns, the maximum is 2")
+		record, err = reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "last", AnInt: 3}, record)
+		require.Equal(4, reader.Row())
+	})
+}
+
+func TestReader_RequireColumns(t *testing.T) {
+	const data = "a_string,an_int,extra\nstring,11,x\n"
+	t.Run("present", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		require.NoError(reader.RequireColumns("an_int", "extra"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "string", AnInt: 11}, record)
+	})
+	t.Run("missing", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		require.EqualError(reader.RequireColumns("an_int", "a_float", "a_bool"), "missing required columns: a_float, a_bool")
+	})
+	t.Run("exact", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		require.NoError(reader.RequireExactColumns("extra", "a_string", "an_int"))
+		reader = NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		require.EqualError(reader.RequireExactColumns("a_string", "an_int"), "unexpected columns: extra")
+	})
+	t.Run("independent of the record", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		reader.StrictMode = true
+		require.NoError(reader.RequireColumns("a_string", "extra"))
+		// StrictMode is still checked on the first read
+		_, err := reader.Next()
+		require.EqualError(err, "extra was seen in the csv but not in the record provided")
+
+		type requiredRecord struct {
+			AString string `csv:"a_string"`
+			Missing string `csv:"missing,required"`
+		}
+		required := NewStructuredCSVReader[requiredRecord](strings.NewReader(data))
+		require.NoError(required.RequireExactColumns("a_string", "an_int", "extra"))
+		_, err = required.Next()
+		require.EqualError(err, "missing is a required column but was not seen in the csv")
+	})
+}
+
+func TestReader_AllowThousandsCommas(t *testing.T) {
+	const data = "a_string,an_int,a_float\n\"1,234\",\"1,234,567\",\"12,345.5\"\n"
+	t.Run("enabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		reader.AllowThousandsCommas = true
+		record, err := reader.Next()
+		require.NoError(err)
+		// Strings are left alone
+		require.Equal(simpleCSVRecord{AString: "1,234", AnInt: 1234567, AFloat: 12345.5}, record)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		_, err := reader.Next()
+		require.ErrorContains(err, "on row 2:")
+	})
+}
+
+func TestReader_ErrNoMoreRecords(t *testing.T) {
+	require := testifyrequire.New(t)
+	reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string\nstring\n"))
+	_, err := reader.Next()
+	require.NoError(err)
+	_, err = reader.Next()
+	require.ErrorIs(err, ErrNoMoreRecords)
+	// Existing io.EOF checks keep working
+	require.ErrorIs(err, io.EOF)
+	_, err = reader.NextRawRow()
+	require.ErrorIs(err, ErrNoMoreRecords)
+}
+
+func TestReader_ReadAll(t *testing.T) {
+	t.Run("all records", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\na,1\nb,2\n"))
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]simpleCSVRecord{{AString: "a", AnInt: 1}, {AString: "b", AnInt: 2}}, records)
+	})
+	t.Run("partial progress", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](strings.NewReader("a_string,a_float,an_int\na,1.5,1\nb,2.5,\nc,3.5,3\n"))
+		records, err := reader.ReadAll()
+		require.EqualError(err, "on row 3: column \"an_int\": an_int is a required field")
+		require.Equal([]requiredCSVRecordStrictFail{{AString: "a", AFloat: 1.5, AnInt: 1}}, records)
+	})
+	t.Run("strict mode", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("surprise\n1\n"))
+		reader.StrictMode = true
+		records, err := reader.ReadAll()
+		require.EqualError(err, "surprise was seen in the csv but not in the record provided")
+		require.Empty(records)
+	})
+}
+
+func TestReader_SetDelimiterAndComment(t *testing.T) {
+	t.Run("semicolons and comments", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("# exported data\na_string;an_int\n# first\na,b;1\n"))
+		require.NoError(reader.SetDelimiter(';'))
+		require.NoError(reader.SetComment('#'))
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]simpleCSVRecord{{AString: "a,b", AnInt: 1}}, records)
+	})
+	t.Run("after the header", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string\na\n"))
+		_, err := reader.Next()
+		require.NoError(err)
+		require.EqualError(reader.SetDelimiter(';'), "the delimiter can not be changed after the header has been read")
+		require.EqualError(reader.SetComment('#'), "the comment can not be changed after the header has been read")
+	})
+}
+
+func TestReader_Column(t *testing.T) {
+	t.Run("present", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\na,1\nb,not a number\n"))
+		cells, err := reader.Column("an_int")
+		require.NoError(err)
+		// Cells are not decoded, so invalid values are returned as-is
+		require.Equal([]string{"1", "not a number"}, cells)
+	})
+	t.Run("missing", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\na,1\n"))
+		_, err := reader.Column("email")
+		require.EqualError(err, "column email was not found in the csv")
+	})
+}
+
+func TestReader_FloatTolerance(t *testing.T) {
+	type float32Record struct {
+		Value float32 `csv:"value"`
+	}
+	t.Run("in tolerance", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[float32Record](strings.NewReader("value\n0.1\n16777217\n\n"))
+		reader.FloatTolerance = 1e-6
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]float32Record{{Value: 0.1}, {Value: 16777216}}, records)
+	})
+	t.Run("out of tolerance", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[float32Record](strings.NewReader("value\n0.5\n16777217\n"))
+		reader.FloatTolerance = 1e-9
+		_, err := reader.Next()
+		require.NoError(err)
+		_, err = reader.Next()
+		require.ErrorContains(err, "on row 3: column \"value\": 16777217 loses precision as 1.6777216e+07")
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[float32Record](strings.NewReader("value\n16777217\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(float32(16777216), record.Value)
+	})
+}
+
+func TestReader_InternStrings(t *testing.T) {
+	type countryRecord struct {
+		Country string `csv:"country"`
+		Code    int    `csv:"code"`
+	}
+	require := testifyrequire.New(t)
+	reader := NewStructuredCSVReader[countryRecord](strings.NewReader("country,code\nNZ,1\nAU,2\nNZ,3\n"))
+	reader.InternStrings = true
+	records, err := reader.ReadAll()
+	require.NoError(err)
+	require.Equal([]countryRecord{{"NZ", 1}, {"AU", 2}, {"NZ", 3}}, records)
+	// Both NZ values share the same backing data
+	require.Same(unsafe.StringData(records[0].Country), unsafe.StringData(records[2].Country))
+	require.Len(reader.interned, 2)
+}
+
+// benchmarkInternStrings reads a file with a low cardinality column and reports the heap retained by the records.
+func benchmarkInternStrings(b *testing.B, intern bool) {
+	type countryRecord struct {
+		Country string `csv:"country"`
+		Notes   string `csv:"notes"`
+	}
+	var data strings.Builder
+	data.WriteString("country,notes\n")
+	countries := []string{"NZ", "AU", "US", "GB", "DE"}
+	for i := 0; i < 10000; i++ {
+		data.WriteString(countries[i%len(countries)] + "," + strings.Repeat("x", 100) + "\n")
+	}
+	b.ReportAllocs()
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		reader := NewStructuredCSVReader[countryRecord](strings.NewReader(data.String()))
+		reader.InternStrings = intern
+		records, err := reader.ReadAll()
+		if err != nil {
+			b.Fatal(err)
+		}
+		// Drop the notes so only the country column keeps its rows alive
+		for idx := range records {
+			records[idx].Notes = ""
+		}
+		reader = nil
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		retained += after.HeapAlloc - min(after.HeapAlloc, before.HeapAlloc)
+		runtime.KeepAlive(records)
+	}
+	b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+}
+
+func BenchmarkReader_Strings(b *testing.B) {
+	benchmarkInternStrings(b, false)
+}
+
+func BenchmarkReader_InternStrings(b *testing.B) {
+	benchmarkInternStrings(b, true)
+}
+
+func TestReader_All(t *testing.T) {
+	t.Run("every record", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\na,1\nb,2\n"))
+		var records []simpleCSVRecord
+		for record, err := range reader.All() {
+			require.NoError(err)
+			records = append(records, record)
+		}
+		require.Equal([]simpleCSVRecord{{AString: "a", AnInt: 1}, {AString: "b", AnInt: 2}}, records)
+	})
+	t.Run("error", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\na,1\nb,two\nc,3\n"))
+		var errs []error
+		var count int
+		for _, err := range reader.All() {
+			count++
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		require.Equal(2, count)
+		require.Len(errs, 1)
+		require.ErrorContains(errs[0], "on row 3:")
+	})
+	t.Run("break", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\na,1\nb,2\n"))
+		for range reader.All() {
+			break
+		}
+		// The reader picks up where the loop left off
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal("b", record.AString)
+	})
+}
+
+func TestReader_CaseInsensitiveHeaders(t *testing.T) {
+	const data = "A_STRING,An_Int\nstring,11\n"
+	t.Run("enabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		reader.CaseInsensitiveHeaders = true
+		reader.StrictMode = true
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "string", AnInt: 11}, record)
+		require.Equal([]string{"a_string", "an_int"}, reader.Headers())
+	})
+	t.Run("strict mode still reports unknown columns", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("A_STRING,Surprise\nstring,x\n"))
+		reader.CaseInsensitiveHeaders = true
+		reader.StrictMode = true
+		_, err := reader.Next()
+		require.EqualError(err, "Surprise was seen in the csv but not in the record provided")
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		reader.StrictMode = true
+		_, err := reader.Next()
+		require.EqualError(err, "A_STRING was seen in the csv but not in the record provided")
+	})
+}
+
+func TestReader_TrimSpace(t *testing.T) {
+	t.Run("enabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[defaultRecord](strings.NewReader("name,age,balance\n  alice , 32 , 1.5 \n ,   ,2\n"))
+		reader.TrimSpace = true
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]defaultRecord{
+			{Name: "alice", Age: 32, Balance: 1.5},
+			// Whitespace only cells are null, so the defaults apply
+			{Name: "anonymous", Age: 18, Balance: 2},
+		}, records)
+	})
+	t.Run("required", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[defaultRecord](strings.NewReader("name,balance\nalice,  \n"))
+		reader.TrimSpace = true
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"balance\": balance is a required field")
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\n  alice , 32 \n"))
+		_, err := reader.Next()
+		require.ErrorContains(err, "on row 2:")
+	})
+}
+
+func TestReader_Header(t *testing.T) {
+	t.Run("before reading rows", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int,extra\nstring,11,x\n"))
+		header, err := reader.Header()
+		require.NoError(err)
+		require.Equal([]string{"a_string", "an_int", "extra"}, header)
+		// Mutating the copy doesn't touch the reader
+		header[0] = "changed"
+		again, err := reader.Header()
+		require.NoError(err)
+		require.Equal([]string{"a_string", "an_int", "extra"}, again)
+		// The first data row hasn't been consumed
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "string", AnInt: 11}, record)
+	})
+	t.Run("empty input", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(""))
+		_, err := reader.Header()
+		require.ErrorIs(err, io.EOF)
+	})
+}
+
+func TestReader_NextContext(t *testing.T) {
+	const data = "a_string,an_int\na,1\nb,2\nc,3\n"
+	t.Run("cancelled between rows", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		record, err := reader.NextContext(ctx)
+		require.NoError(err)
+		require.Equal("a", record.AString)
+		cancel()
+		_, err = reader.NextContext(ctx)
+		require.ErrorIs(err, context.Canceled)
+		require.EqualError(err, "on row 3: context canceled")
+	})
+	t.Run("read all", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		records, err := reader.ReadAllContext(context.Background())
+		require.NoError(err)
+		require.Len(records, 3)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		reader = NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		records, err = reader.ReadAllContext(ctx)
+		require.ErrorIs(err, context.Canceled)
+		require.Empty(records)
+	})
+}
+
+func TestReader_NoHeader(t *testing.T) {
+	t.Run("fields in declaration order", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a,1.5,2,true\nb,0,3,false\n"))
+		reader.NoHeader = true
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]simpleCSVRecord{
+			{AString: "a", AFloat: 1.5, AnInt: 2, ABool: true},
+			{AString: "b", AnInt: 3},
+		}, records)
+		require.Equal([]string{"a_string", "a_float", "an_int", "a_bool"}, reader.Headers())
+	})
+	t.Run("first row is row 1", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a,1.5,x,true\n"))
+		reader.NoHeader = true
+		_, err := reader.Next()
+		require.EqualError(err, `on row 1: column "an_int": strconv.ParseInt: parsing "x": invalid syntax`)
+	})
+	t.Run("wrong number of cells", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a,1.5,2,true,extra\n"))
+		reader.NoHeader = true
+		_, err := reader.Next()
+		require.ErrorContains(err, "wrong number of fields")
+	})
+}
+
+func TestReader_NullValues(t *testing.T) {
+	type nullRecord struct {
+		Name  string `csv:"name,required"`
+		Age   int    `csv:"age,default=18"`
+		Score int    `csv:"score,null=NA|-"`
+	}
+	t.Run("reader and tag sentinels", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nullRecord](strings.NewReader("name,age,score\nalice,\\N,NA\nbob,NULL,-\ncarol,40,7\n"))
+		reader.NullValues = []string{"NULL", `\N`}
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]nullRecord{
+			{Name: "alice", Age: 18},
+			{Name: "bob", Age: 18},
+			{Name: "carol", Age: 40, Score: 7},
+		}, records)
+	})
+	t.Run("required", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nullRecord](strings.NewReader("name,age,score\nNULL,1,2\n"))
+		reader.NullValues = []string{"NULL"}
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "name": name is a required field`)
+	})
+	t.Run("case sensitivity", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nullRecord](strings.NewReader("name,age,score\nnull,null,na\n"))
+		reader.NullValues = []string{"NULL"}
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "age": strconv.ParseInt: parsing "null": invalid syntax`)
+
+		reader = NewStructuredCSVReader[nullRecord](strings.NewReader("name,age,score\nalice,null,na\n"))
+		reader.NullValues = []string{"NULL"}
+		reader.NullValuesIgnoreCase = true
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(nullRecord{Name: "alice", Age: 18}, record)
+	})
+}
+
+func TestReader_Reset(t *testing.T) {
+	require := testifyrequire.New(t)
+	reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string;an_int\na;1\nb;2\n"))
+	require.NoError(reader.SetDelimiter(';'))
+	records, err := reader.ReadAll()
+	require.NoError(err)
+	require.Len(records, 2)
+
+	// The new source has its own header with a different column order and count
+	reader.Reset(strings.NewReader("an_int;a_bool;a_string\n3;true;c\n"))
+	require.Empty(reader.Headers())
+	record, err := reader.Next()
+	require.NoError(err)
+	require.Equal(simpleCSVRecord{AString: "c", AnInt: 3, ABool: true}, record)
+	require.Equal([]string{"an_int", "a_bool", "a_string"}, reader.Headers())
+	// Row numbers start over
+	reader.Reset(strings.NewReader("an_int\nx\n"))
+	_, err = reader.Next()
+	require.EqualError(err, `on row 2: column "an_int": strconv.ParseInt: parsing "x": invalid syntax`)
+}
+
+func TestReader_Row(t *testing.T) {
+	require := testifyrequire.New(t)
+	reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,an_int\na,1\nb,2\n"))
+	require.Equal(0, reader.Row())
+	_, err := reader.Next()
+	require.NoError(err)
+	require.Equal(2, reader.Row())
+	_, err = reader.Next()
+	require.NoError(err)
+	require.Equal(3, reader.Row())
+	_, err = reader.Next()
+	require.ErrorIs(err, ErrNoMoreRecords)
+	require.Equal(3, reader.Row())
+}
+
+func TestReader_ShortRows(t *testing.T) {
+	t.Run("required trailing column", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[requiredCSVRecordStrictFail](strings.NewReader("a_string,a_float,an_int\nstring,1\n"))
+		reader.AllowVariableColumns(true)
+		_, err := reader.Next()
+		require.EqualError(err, `on row 2: column "an_int": an_int is a required field`)
+	})
+	t.Run("optional trailing columns", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader("a_string,a_float,an_int,a_bool\nstring,1.5\n"))
+		reader.AllowVariableColumns(true)
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: "string", AFloat: 1.5}, record)
+	})
+}
+
+func TestReader_AllowVariableColumns(t *testing.T) {
+	const data = "a_string,an_int\na,1\nb\nc,3,extra\n"
+	t.Run("enabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		reader.AllowVariableColumns(true)
+		records, err := reader.ReadAll()
+		require.NoError(err)
+		require.Equal([]simpleCSVRecord{{AString: "a", AnInt: 1}, {AString: "b"}, {AString: "c", AnInt: 3}}, records)
+	})
+	t.Run("column", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		reader.AllowVariableColumns(true)
+		cells, err := reader.Column("an_int")
+		require.NoError(err)
+		require.Equal([]string{"1", "", "3"}, cells)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		reader.AllowVariableColumns(true)
+		reader.AllowVariableColumns(false)
+		_, err := reader.ReadAll()
+		require.ErrorIs(err, csv.ErrFieldCount)
+	})
+}
+
+func TestReader_SetLazyQuotes(t *testing.T) {
+	const data = "a_string,an_int\n5\" pipe,1\n"
+	t.Run("enabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		reader.SetLazyQuotes(true)
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(simpleCSVRecord{AString: `5" pipe`, AnInt: 1}, record)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(data))
+		_, err := reader.Next()
+		require.ErrorIs(err, csv.ErrBareQuote)
+	})
+}
@@ -0,0 +1,88 @@
+package csv
+
+import (
+	"io"
+
+	"github.com/weisbartb/stack"
+)
+
+// SplitWriter writes records across multiple files, each holding at most a fixed number of records.
+// Every file gets its own header.
+type SplitWriter[Record any] struct {
+	// ConfigureWriter is called with the Writer for each file before anything is written to it,
+	// so options such as the delimiter or IncludeRowNumber apply to every file.
+	ConfigureWriter   func(writer *Writer[Record])
+	factory           func(part int) (io.WriteCloser, error)
+	maxRecordsPerFile int
+	// part is the index of the current file
+	part int
+	// count is the number of records written to the current file
+	count   int
+	current io.WriteCloser
+	writer  *Writer[Record]
+}
+
+// NewSplitWriter makes a new writer that splits records across files created by the factory.
+// part starts at 0 and increments each time a file is filled, a maxRecordsPerFile of 0 or less never splits.
+func NewSplitWriter[Record any](factory func(part int) (io.WriteCloser, error), maxRecordsPerFile int) *SplitWriter[Record] {
+	return &SplitWriter[Record]{
+		factory:           factory,
+		maxRecordsPerFile: maxRecordsPerFile,
+	}
+}
+
+// WriteRecord writes record(s), rolling over to a new file whenever the current one is full.
+func (s *SplitWriter[Record]) WriteRecord(items ...Record) error {
+	for _, item := range items {
+		if s.current == nil || (s.maxRecordsPerFile > 0 && s.count >= s.maxRecordsPerFile) {
+			if err := s.roll(); err != nil {
+				return stack.Trace(err)
+			}
+		}
+		if err := s.writer.WriteRecord(item); err != nil {
+			return stack.Trace(err)
+		}
+		s.count++
+	}
+	return nil
+}
+
+// roll closes out the current file and opens the next one.
+func (s *SplitWriter[Record]) roll() error {
+	if s.current != nil {
+		if err := s.closeCurrent(); err != nil {
+			return stack.Trace(err)
+		}
+		s.part++
+	}
+	fh, err := s.factory(s.part)
+	if err != nil {
+		return stack.Trace(err)
+	}
+	s.current = fh
+	s.writer = NewWriter[Record](fh)
+	if s.ConfigureWriter != nil {
+		s.ConfigureWriter(s.writer)
+	}
+	s.count = 0
+	return nil
+}
+
+// closeCurrent flushes and closes the current file.
+func (s *SplitWriter[Record]) closeCurrent() error {
+	flushErr := s.writer.Flush()
+	closeErr := s.current.Close()
+	s.current = nil
+	if flushErr != nil {
+		return stack.Trace(flushErr)
+	}
+	return stack.Trace(closeErr)
+}
+
+// Close finalizes the last file, it is safe to call if nothing was written.
+func (s *SplitWriter[Record]) Close() error {
+	if s.current == nil {
+		return nil
+	}
+	return s.closeCurrent()
+}
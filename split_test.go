@@ -0,0 +1,71 @@
+package csv
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+// closableBuffer tracks if the buffer was closed.
+type closableBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closableBuffer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestSplitWriter(t *testing.T) {
+	require := testifyrequire.New(t)
+	var files []*closableBuffer
+	var parts []int
+	writer := NewSplitWriter[testWriterStruct](func(part int) (io.WriteCloser, error) {
+		parts = append(parts, part)
+		files = append(files, &closableBuffer{})
+		return files[len(files)-1], nil
+	}, 2)
+	require.NoError(writer.WriteRecord(
+		testWriterStruct{Email: "a@example.com", Age: 1},
+		testWriterStruct{Email: "b@example.com", Age: 2},
+		testWriterStruct{Email: "c@example.com", Age: 3},
+	))
+	require.NoError(writer.WriteRecord(testWriterStruct{Email: "d@example.com", Age: 4}))
+	require.NoError(writer.WriteRecord(testWriterStruct{Email: "e@example.com", Age: 5}))
+	require.NoError(writer.Close())
+	require.Equal([]int{0, 1, 2}, parts)
+	require.Len(files, 3)
+	require.Equal("email,age,owed,\na@example.com,1,0,FALSE\nb@example.com,2,0,FALSE\n", files[0].String())
+	require.Equal("email,age,owed,\nc@example.com,3,0,FALSE\nd@example.com,4,0,FALSE\n", files[1].String())
+	require.Equal("email,age,owed,\ne@example.com,5,0,FALSE\n", files[2].String())
+	for _, f := range files {
+		require.True(f.closed)
+	}
+	// Closing again is a no-op
+	require.NoError(writer.Close())
+}
+
+func TestSplitWriter_ConfigureWriter(t *testing.T) {
+	require := testifyrequire.New(t)
+	var files []*closableBuffer
+	writer := NewSplitWriter[testWriterStruct](func(part int) (io.WriteCloser, error) {
+		files = append(files, &closableBuffer{})
+		return files[len(files)-1], nil
+	}, 1)
+	writer.ConfigureWriter = func(w *Writer[testWriterStruct]) {
+		require.NoError(w.SetDelimiter(';'))
+		w.IncludeRowNumber = true
+	}
+	require.NoError(writer.WriteRecord(
+		testWriterStruct{Email: "a@example.com", Age: 1},
+		testWriterStruct{Email: "b@example.com", Age: 2},
+	))
+	require.NoError(writer.Close())
+	require.Len(files, 2)
+	// Every file is configured and numbers its rows from 1
+	require.Equal("row_number;email;age;owed;\n1;a@example.com;1;0;FALSE\n", files[0].String())
+	require.Equal("row_number;email;age;owed;\n1;b@example.com;2;0;FALSE\n", files[1].String())
+}
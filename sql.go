@@ -0,0 +1,61 @@
+package csv
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"reflect"
+
+	"github.com/weisbartb/stack"
+)
+
+// WriteSQLRows writes every row from a database query as a CSV, the column names are used as the header.
+// Values are stringified through the scalar encoders, []byte values are written as text and NULL values as empty cells.
+// The rows are not closed.
+func WriteSQLRows(w io.Writer, rows *sql.Rows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return stack.Trace(err)
+	}
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+	if err := writer.Write(columns); err != nil {
+		return stack.Trace(err)
+	}
+	values := make([]any, len(columns))
+	scanTargets := make([]any, len(columns))
+	for i := range values {
+		scanTargets[i] = &values[i]
+	}
+	row := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(scanTargets...); err != nil {
+			return stack.Trace(err)
+		}
+		for i, v := range values {
+			if row[i], err = encodeSQLValue(v); err != nil {
+				return stack.Wrap(err, columns[i])
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return stack.Trace(err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return stack.Trace(err)
+	}
+	writer.Flush()
+	return stack.Trace(writer.Error())
+}
+
+// encodeSQLValue stringifies a value scanned from a database.
+func encodeSQLValue(v any) (string, error) {
+	switch typed := v.(type) {
+	case nil:
+		return "", nil
+	case []byte:
+		return string(typed), nil
+	}
+	vOf := reflect.ValueOf(v)
+	return getEncoderProvider(vOf.Type(), false)(vOf)
+}
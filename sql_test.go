@@ -0,0 +1,72 @@
+package csv
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+// staticDriver is a minimal database driver that returns a fixed result set for any query.
+type staticDriver struct{}
+
+func (staticDriver) Open(string) (driver.Conn, error) { return staticConn{}, nil }
+
+type staticConn struct{}
+
+func (staticConn) Prepare(string) (driver.Stmt, error) { return staticStmt{}, nil }
+func (staticConn) Close() error                        { return nil }
+func (staticConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+type staticStmt struct{}
+
+func (staticStmt) Close() error                               { return nil }
+func (staticStmt) NumInput() int                              { return 0 }
+func (staticStmt) Exec([]driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+func (staticStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &staticRows{rows: [][]driver.Value{
+		{int64(1), []byte("alice, the first"), 12.5, true, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{int64(2), nil, nil, false, nil},
+	}}, nil
+}
+
+type staticRows struct {
+	rows [][]driver.Value
+}
+
+func (*staticRows) Columns() []string {
+	return []string{"id", "name", "balance", "active", "created"}
+}
+func (*staticRows) Close() error { return nil }
+func (s *staticRows) Next(dest []driver.Value) error {
+	if len(s.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, s.rows[0])
+	s.rows = s.rows[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("csv-static", staticDriver{})
+}
+
+func TestWriteSQLRows(t *testing.T) {
+	require := testifyrequire.New(t)
+	db, err := sql.Open("csv-static", "")
+	require.NoError(err)
+	defer db.Close()
+	rows, err := db.Query("SELECT")
+	require.NoError(err)
+	defer rows.Close()
+	buf := bytes.Buffer{}
+	require.NoError(WriteSQLRows(&buf, rows))
+	require.Equal(
+		"id,name,balance,active,created\n1,\"alice, the first\",12.5,TRUE,2024-01-02T03:04:05Z\n2,,,FALSE,\n",
+		buf.String(),
+	)
+}
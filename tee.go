@@ -0,0 +1,32 @@
+package csv
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// teeWriter duplicates every write to each of its destinations in order.
+type teeWriter struct {
+	writers []io.Writer
+}
+
+// Write writes to every destination, stopping at the first one that fails.
+func (t *teeWriter) Write(p []byte) (int, error) {
+	for idx, w := range t.writers {
+		n, err := w.Write(p)
+		if err == nil && n != len(p) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return n, fmt.Errorf("writing to destination %v: %w", idx, err)
+		}
+	}
+	return len(p), nil
+}
+
+// NewTeeWriter makes a new CSV writer that writes the header and every row to all the destinations in one pass.
+// A write error on any destination aborts the write, the error includes the index of the destination that failed.
+func NewTeeWriter[Record any](writers ...io.Writer) *Writer[Record] {
+	return NewWriter[Record](&teeWriter{writers: slices.Clone(writers)})
+}
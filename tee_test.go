@@ -0,0 +1,29 @@
+package csv
+
+import (
+	"bytes"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+func TestNewTeeWriter(t *testing.T) {
+	t.Run("every destination", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		var a, b bytes.Buffer
+		writer := NewTeeWriter[testWriterFormulaStruct](&a, &b)
+		writer.WriteBOM = true
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "a", Balance: 1}))
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "b", Balance: 2}))
+		require.NoError(writer.Flush())
+		require.Equal(utf8BOM+"comment,balance\na,1\nb,2\n", a.String())
+		require.Equal(a.String(), b.String())
+	})
+	t.Run("failing destination", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		var a bytes.Buffer
+		writer := NewTeeWriter[testWriterFormulaStruct](&a, failingWriter{})
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "a", Balance: 1}))
+		require.EqualError(writer.Flush(), "writing to destination 1: disk full")
+	})
+}
@@ -0,0 +1,68 @@
+package csv
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
+
+// recordTerminatorReader translates a custom record terminator into a newline so the stdlib reader can parse it.
+// Quoting is tracked so terminators inside of a quoted field are left untouched.
+//
+// Limitations:
+//   - Only the standard double quote is treated as a quote character.
+//   - Newlines that appear outside a quoted field are passed through and will still end a record.
+type recordTerminatorReader struct {
+	src        *bufio.Reader
+	terminator rune
+	// inQuotes tracks if the current position is inside a quoted field
+	inQuotes bool
+	// pending holds encoded bytes that did not fit into the last buffer
+	pending []byte
+}
+
+func newRecordTerminatorReader(src io.Reader, terminator rune) *recordTerminatorReader {
+	return &recordTerminatorReader{
+		src:        bufio.NewReader(src),
+		terminator: terminator,
+	}
+}
+
+// Read implements io.Reader
+func (t *recordTerminatorReader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		if len(t.pending) > 0 {
+			copied := copy(p[n:], t.pending)
+			t.pending = t.pending[copied:]
+			n += copied
+			continue
+		}
+		if n > 0 && t.src.Buffered() == 0 {
+			// Don't block on the source if there is already data to hand back
+			break
+		}
+		r, size, err := t.src.ReadRune()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if r == utf8.RuneError && size == 1 {
+			// Pass invalid bytes through as-is
+			_ = t.src.UnreadRune()
+			b, _ := t.src.ReadByte()
+			p[n] = b
+			n++
+			continue
+		}
+		if r == '"' {
+			t.inQuotes = !t.inQuotes
+		} else if r == t.terminator && !t.inQuotes {
+			r = '\n'
+		}
+		t.pending = utf8.AppendRune(t.pending[:0], r)
+	}
+	return n, nil
+}
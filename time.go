@@ -0,0 +1,141 @@
+package csv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Unix epoch precisions for time.Time fields
+const (
+	timeUnixSeconds      = "unix"
+	timeUnixMilliseconds = "unixms"
+)
+
+// getUnixTimeEncoder returns an encoder that writes a time.Time field as a Unix epoch integer.
+func getUnixTimeEncoder(fieldType reflect.Type, precision string, omitEmpty bool) encoderFunction {
+	if fieldType != tOfTime {
+		return func(val reflect.Value) (string, error) {
+			return "", fmt.Errorf("%v can only be used with time.Time, not %v", precision, fieldType)
+		}
+	}
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		t := val.Interface().(time.Time)
+		if precision == timeUnixMilliseconds {
+			return strconv.FormatInt(t.UnixMilli(), 10), nil
+		}
+		return strconv.FormatInt(t.Unix(), 10), nil
+	}
+}
+
+// getUnixTimeDecoder returns a decoder that parses a Unix epoch integer into a time.Time field (in UTC).
+func getUnixTimeDecoder(fieldType reflect.Type, fieldName string, precision string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	if fieldType != tOfTime {
+		return func(s string, isNull bool) (any, error) {
+			return nil, fmt.Errorf("%v can only use %v with time.Time, not %v", fieldName, precision, fieldType)
+		}
+	}
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		if len(s) == 0 {
+			return time.Time{}, nil
+		}
+		epoch, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if precision == timeUnixMilliseconds {
+			return time.UnixMilli(epoch).UTC(), nil
+		}
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+}
+
+// namedTimeLayouts hold the time package layouts that can be used by name with format=, e.g. format=RFC3339Nano.
+var namedTimeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// resolveTimeLayout converts a format= value into a layout, RFC 3339 with nanoseconds is used if it is empty
+// so untagged fields keep the sub-second precision MarshalText writes.
+func resolveTimeLayout(layout string) string {
+	if len(layout) == 0 {
+		return time.RFC3339Nano
+	}
+	if named, ok := namedTimeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+// isTimeField checks if a field is a time.Time or a *time.Time.
+func isTimeField(fieldType reflect.Type) bool {
+	return fieldType == tOfTime || fieldType == reflect.PointerTo(tOfTime)
+}
+
+// getTimeEncoder returns an encoder that formats a time.Time field with a layout, see resolveTimeLayout.
+func getTimeEncoder(fieldType reflect.Type, layout string, omitEmpty bool) encoderFunction {
+	layout = resolveTimeLayout(layout)
+	zeroerFunc := getZeroFunction(fieldType)
+	return func(val reflect.Value) (string, error) {
+		if omitEmpty && zeroerFunc(val) {
+			return "", nil
+		}
+		if val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return "", nil
+			}
+			val = val.Elem()
+		}
+		return val.Interface().(time.Time).Format(layout), nil
+	}
+}
+
+// getTimeDecoder returns a decoder that parses a time.Time field with a layout, see resolveTimeLayout.
+// An empty cell yields the zero time, or a nil pointer for a *time.Time field.
+func getTimeDecoder(fieldType reflect.Type, fieldName string, layout string, required bool) decoderFunction {
+	var errFieldRequired error = &RequiredFieldError{Field: fieldName}
+	// Parsing with RFC 3339 accepts fractional seconds, so RFC3339 and RFC3339Nano both decode either form
+	layout = resolveTimeLayout(layout)
+	isPtr := fieldType.Kind() == reflect.Ptr
+	return func(s string, isNull bool) (any, error) {
+		if required && isNull {
+			return nil, errFieldRequired
+		}
+		if len(s) == 0 {
+			if isPtr {
+				return (*time.Time)(nil), nil
+			}
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a valid time: %w", fieldName, err)
+		}
+		if isPtr {
+			return &t, nil
+		}
+		return t, nil
+	}
+}
+
+// getErrorCodec returns an encoder and decoder that always fail, this is used for tags that can't be applied to a field.
+func getErrorCodec(err error) (encoderFunction, decoderFunction) {
+	return func(val reflect.Value) (string, error) {
+			return "", err
+		}, func(s string, isNull bool) (any, error) {
+			return nil, err
+		}
+}
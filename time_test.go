@@ -0,0 +1,150 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type unixTimeRecord struct {
+	Seconds      time.Time `csv:"seconds,unix"`
+	Milliseconds time.Time `csv:"milliseconds,unixms"`
+}
+
+type conflictingTimeRecord struct {
+	Created time.Time `csv:"created,unix,layout=2006-01-02"`
+}
+
+func TestUnixTime(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		ts := time.Date(2024, 3, 4, 5, 6, 7, 891_000_000, time.UTC)
+		buf := bytes.Buffer{}
+		writer := NewWriter[unixTimeRecord](&buf)
+		require.NoError(writer.WriteRecord(unixTimeRecord{Seconds: ts, Milliseconds: ts}))
+		require.NoError(writer.Flush())
+		require.Equal("seconds,milliseconds\n1709528767,1709528767891\n", buf.String())
+
+		reader := NewStructuredCSVReader[unixTimeRecord](&buf)
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(ts.Truncate(time.Second), record.Seconds)
+		require.Equal(ts, record.Milliseconds)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[unixTimeRecord](strings.NewReader("seconds\nyesterday\n"))
+		_, err := reader.Next()
+		require.ErrorContains(err, "on row 2: column \"seconds\": strconv.ParseInt")
+	})
+	t.Run("conflicts with layout", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[conflictingTimeRecord](strings.NewReader("created\n1\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"created\": created can not use both unix and format=")
+		writer := NewWriter[conflictingTimeRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(conflictingTimeRecord{}), "created can not use both unix and format=")
+	})
+}
+
+type layoutTimeRecord struct {
+	Created time.Time  `csv:"created,format=2006-01-02"`
+	Updated time.Time  `csv:"updated"`
+	Deleted *time.Time `csv:"deleted,layout=02/01/2006 15:04"`
+}
+
+func TestTimeLayout(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		deleted := time.Date(2024, 3, 5, 16, 30, 0, 0, time.UTC)
+		records := []layoutTimeRecord{
+			{
+				Created: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+				Updated: time.Date(2024, 3, 4, 5, 6, 7, 0, time.FixedZone("", 2*60*60)),
+				Deleted: &deleted,
+			},
+			{Created: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[layoutTimeRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("created,updated,deleted\n2024-03-04,2024-03-04T05:06:07+02:00,05/03/2024 16:30\n2024-03-04,0001-01-01T00:00:00Z,\n", buf.String())
+
+		reader := NewStructuredCSVReader[layoutTimeRecord](&buf)
+		out, err := reader.ReadAll()
+		require.NoError(err)
+		require.Len(out, 2)
+		require.True(records[0].Updated.Equal(out[0].Updated))
+		require.Equal(records[0].Created, out[0].Created)
+		require.Equal(deleted, *out[0].Deleted)
+		require.Nil(out[1].Deleted)
+	})
+	t.Run("empty", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[layoutTimeRecord](strings.NewReader("created,updated\n,2024-03-04T05:06:07Z\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.True(record.Created.IsZero())
+	})
+	t.Run("malformed", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[layoutTimeRecord](strings.NewReader("created\n04/03/2024\n"))
+		_, err := reader.Next()
+		require.ErrorContains(err, `on row 2: column "created": created is not a valid time: parsing time "04/03/2024"`)
+	})
+}
+
+func TestTimeRFC3339Nano(t *testing.T) {
+	type nanoRecord struct {
+		At time.Time `csv:"at,layout=RFC3339Nano"`
+	}
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		ts := time.Date(2024, 3, 4, 5, 6, 7, 123_456_789, time.UTC)
+		buf := bytes.Buffer{}
+		writer := NewWriter[nanoRecord](&buf)
+		require.NoError(writer.WriteRecord(nanoRecord{At: ts}))
+		require.NoError(writer.Flush())
+		require.Equal("at\n2024-03-04T05:06:07.123456789Z\n", buf.String())
+		reader := NewStructuredCSVReader[nanoRecord](&buf)
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(ts, record.At)
+	})
+	t.Run("accepts RFC3339", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[nanoRecord](strings.NewReader("at\n2024-03-04T05:06:07Z\n"))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC), record.At)
+	})
+	t.Run("default keeps nanoseconds", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		ts := time.Date(2024, 1, 2, 3, 4, 5, 123_456_789, time.UTC)
+		buf := bytes.Buffer{}
+		writer := NewWriter[layoutTimeRecord](&buf)
+		require.NoError(writer.WriteRecord(layoutTimeRecord{Updated: ts}))
+		require.NoError(writer.Flush())
+		// An untagged field is written as RFC3339Nano, the same as MarshalText
+		require.Equal("created,updated,deleted\n0001-01-01,2024-01-02T03:04:05.123456789Z,\n", buf.String())
+		reader := NewStructuredCSVReader[layoutTimeRecord](&buf)
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(ts, record.Updated)
+	})
+	t.Run("RFC3339 truncates", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		type secondsRecord struct {
+			At time.Time `csv:"at,format=RFC3339"`
+		}
+		buf := bytes.Buffer{}
+		writer := NewWriter[secondsRecord](&buf)
+		require.NoError(writer.WriteRecord(secondsRecord{At: time.Date(2024, 3, 4, 5, 6, 7, 500, time.UTC)}))
+		require.NoError(writer.Flush())
+		require.Equal("at\n2024-03-04T05:06:07Z\n", buf.String())
+	})
+}
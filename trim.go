@@ -0,0 +1,12 @@
+package csv
+
+import "strings"
+
+// getTrimDecoder wraps a decoder to strip the trim= tag cutset from both ends of a cell before it is decoded.
+// A cell that is only made up of the cutset becomes null.
+func getTrimDecoder(decoder decoderFunction, cutset string) decoderFunction {
+	return func(s string, isNull bool) (any, error) {
+		s = strings.Trim(s, cutset)
+		return decoder(s, len(s) == 0)
+	}
+}
@@ -0,0 +1,33 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type trimRecord struct {
+	Name  string `csv:"name,trim=\"' "`
+	Code  string `csv:"code,trim=#-"`
+	Count int    `csv:"count,trim= *,required"`
+}
+
+func TestTrim(t *testing.T) {
+	t.Run("mixed cutsets", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[trimRecord](strings.NewReader(
+			"name,code,count\n\"\"\"'alice' \"\"\",##a-b--, *12* \n",
+		))
+		record, err := reader.Next()
+		require.NoError(err)
+		// Only the ends are trimmed
+		require.Equal(trimRecord{Name: "alice", Code: "a-b", Count: 12}, record)
+	})
+	t.Run("trimmed to null", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		reader := NewStructuredCSVReader[trimRecord](strings.NewReader("name,code,count\nalice,#,**\n"))
+		_, err := reader.Next()
+		require.EqualError(err, "on row 2: column \"count\": count is a required field")
+	})
+}
@@ -2,67 +2,553 @@ package csv
 
 import (
 	"encoding/csv"
+	"errors"
+	"fmt"
 	"io"
 	"reflect"
+	"slices"
+	"strconv"
 
 	"github.com/weisbartb/rcache"
 	"github.com/weisbartb/stack"
 )
 
+// utf8BOM is the UTF-8 encoded byte-order mark
+const utf8BOM = "\ufeff"
+
 // Writer holds the state of the CSV writer
 type Writer[Record any] struct {
+	// OnRowError is called when a record fails to encode.
+	// Returning true skips the record and continues writing, false aborts with the error.
+	// index is the position of the record in the call that was writing it.
+	OnRowError func(index int, record Record, err error) bool
+	// SanitizeFormulas escapes string cells that a spreadsheet would evaluate as a formula (CSV injection).
+	// Cells starting with =, +, -, @, tab, or carriage return are prefixed with FormulaEscape.
+	SanitizeFormulas bool
+	// FormulaEscape is the prefix used by SanitizeFormulas, a single quote is used if this is empty.
+	FormulaEscape string
+	// IncludeRowNumber prepends a column holding an incrementing row number (starting at 1) to every row.
+	IncludeRowNumber bool
+	// RowNumberColumn is the header for the IncludeRowNumber column, row_number is used if this is empty.
+	RowNumberColumn string
+	// WriteBOM writes a UTF-8 byte-order mark before the header so Excel detects the encoding.
+	// The mark is only written once per destination, setting this after the header has been written has no effect until Reset.
+	WriteBOM bool
+	// NullOutput is written in place of an unset NullableField (or any field with an IsNull() bool method that reports true), e.g. \N or NULL.
+	// A nullable set to an empty string is still written as an empty cell, so the two can be told apart.
+	NullOutput string
+	// NoHeader skips writing the header row, the columns are written in the order the record's fields are declared.
+	NoHeader bool
+	// SparseKV writes each row as field=value cells for only the fields that encode to a non-empty value, rather than fixed columns.
+	// No header is written in this mode.
+	SparseKV bool
+	// RowHook is called with the final cells of every row before it is written, including the row number column.
+	// The returned cells are written instead and must have the same number of cells as the header.
+	RowHook func(cells []string) ([]string, error)
+	// FlushOnWrite flushes at the end of every WriteRecord call so the rows reach the destination right away, this is off by default.
+	// Without it writes stay buffered and Flush must be called when the bytes should hit the wire.
+	FlushOnWrite bool
+	// FlushBytesThreshold buffers writes until roughly this many bytes are pending, this takes the place of FlushOnWrite.
+	// The size is approximated from the encoded cells, Flush must be called to write out the remainder.
+	FlushBytesThreshold int
+	// skipEmptyRows drops rows whose cells all encode to empty strings, see SkipEmptyRows
+	skipEmptyRows bool
+	// bufferedBytes is the approximate number of bytes written since the last flush
+	bufferedBytes int
+	// locale holds the formatting conventions set by SetLocale
+	locale *Locale
+	// encrypters hold the hooks set by SetCellEncrypter for each column
+	encrypters map[string]func(string) (string, error)
+	// rowNumber is the number of data rows written so far
+	rowNumber     int
 	headerWritten bool
 	instruction   *rcache.FieldCache[csvInstruction]
-	w             *csv.Writer
+	// columns hold the header names in the order they are written
+	columns []string
+	// fields hold the field for each column, a nil field is written as a blank cell or from the extras field
+	fields []*recordField
+	// extras is the field tagged with extras whose keys are written as extra columns, it is nil if the record has none
+	extras *recordField
+	// extraColumns hold the extras keys that are written after the columns, collected from the records in the first write
+	extraColumns []string
+	// out holds the destination the writer was created with
+	out io.Writer
+	w   *csv.Writer
 }
 
 // NewWriter makes a new CSV writer
 func NewWriter[Record any](writer io.Writer) *Writer[Record] {
 	var T Record
+	return newWriter[Record](writer, fieldCache.GetTypeDataFor(reflect.TypeOf(T)))
+}
+
+// newWriter makes a writer that encodes records of the struct the instruction was built from.
+// This lets a writer be made for a type only known at runtime (see nested), the Record type is then only a placeholder.
+func newWriter[Record any](writer io.Writer, instruction *rcache.FieldCache[csvInstruction]) *Writer[Record] {
+	fields := columnFields(instruction)
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.InstructionData().GetCSVHeaderIdentifier())
+	}
 	return &Writer[Record]{
+		out:         writer,
 		w:           csv.NewWriter(writer),
-		instruction: fieldCache.GetTypeDataFor(reflect.TypeOf(T)),
+		instruction: instruction,
+		columns:     columns,
+		fields:      fields,
+		extras:      extrasField(instruction),
 	}
 }
 
-// WriteRecord writes record(s) to the underlying file, a flush is automatically called upon finishing.
-func (c *Writer[Record]) WriteRecord(items ...Record) error {
+// NewWriterWithColumnOrder makes a new CSV writer that writes the given columns in order, such as the Headers from a Reader.
+// Fields that are not in the column list are omitted and columns without a matching field are written blank.
+func NewWriterWithColumnOrder[Record any](writer io.Writer, columns []string) *Writer[Record] {
+	var T Record
+	instruction := fieldCache.GetTypeDataFor(reflect.TypeOf(T))
+	fields := make([]*recordField, 0, len(columns))
+	for _, column := range columns {
+		fields = append(fields, columnField(instruction, column))
+	}
+	return &Writer[Record]{
+		out:         writer,
+		w:           csv.NewWriter(writer),
+		instruction: instruction,
+		columns:     slices.Clone(columns),
+		fields:      fields,
+		extras:      extrasField(instruction),
+	}
+}
+
+// WriteRecord writes record(s) to the underlying file, a flush is called upon finishing if FlushOnWrite is on.
+func (c *Writer[Record]) WriteRecord(items ...Record) (err error) {
 	defer func() {
-		// Flush the buffered IO from the underlying csv-writer
-		c.w.Flush()
+		if c.FlushOnWrite && c.FlushBytesThreshold <= 0 {
+			// Flush the buffered IO from the underlying csv-writer
+			if flushErr := c.Flush(); err == nil {
+				err = flushErr
+			}
+		}
 	}()
+	return stack.Trace(c.writeRecords(items))
+}
+
+// WriteAll writes a slice of records and flushes exactly once at the end, returning any error from flushing.
+func (c *Writer[Record]) WriteAll(items []Record) error {
+	if err := c.writeRecords(items); err != nil {
+		return stack.Trace(err)
+	}
+	return stack.Trace(c.Flush())
+}
+
+// writeRecords writes the header if needed followed by a row for each record, without flushing.
+func (c *Writer[Record]) writeRecords(items []Record) error {
 	if !c.headerWritten {
-		if err := c.writeHeader(); err != nil {
+		if err := c.writeHeader(items); err != nil {
 			return stack.Trace(err)
 		}
 	}
-	for _, item := range items {
-		vOf := reflect.ValueOf(item)
-		var row []string
-		for _, field := range fieldCache.GetTypeDataFor(vOf.Type()).Fields() {
-			val, err := field.InstructionData().encoder(vOf.Field(field.Idx))
-			if err != nil {
+	for idx, item := range items {
+		if err := c.writeItem(idx, item); err != nil {
+			return stack.Trace(err)
+		}
+	}
+	return nil
+}
+
+// writeItem encodes and writes the row for a record, idx is passed to OnRowError.
+func (c *Writer[Record]) writeItem(idx int, item Record) error {
+	row, err := c.encodeRecord(item)
+	if err != nil {
+		if c.OnRowError != nil && c.OnRowError(idx, item, err) {
+			return nil
+		}
+		return stack.Trace(err)
+	}
+	if c.skipEmptyRows && isEmptyRow(row) {
+		return nil
+	}
+	if c.IncludeRowNumber {
+		row = append([]string{strconv.Itoa(c.rowNumber + 1)}, row...)
+	}
+	if c.SparseKV {
+		row = c.sparseRow(row)
+	}
+	if c.RowHook != nil {
+		width := len(row)
+		if row, err = c.RowHook(row); err != nil {
+			return stack.Trace(err)
+		}
+		if len(row) != width {
+			return stack.Trace(fmt.Errorf("row hook returned %v cells but the header has %v", len(row), width))
+		}
+	}
+	if err := c.write(row); err != nil {
+		return stack.Trace(err)
+	}
+	c.rowNumber++
+	return nil
+}
+
+// WriteFrom writes every record received from a channel until it is closed, the header is written with the first record.
+// With FlushOnWrite the rows are flushed whenever the channel has nothing waiting, so a burst of records is flushed once;
+// otherwise FlushBytesThreshold applies. Anything left is flushed once the channel is closed or on an error.
+// This returns on the first error without draining the channel, so the producer must be able to stop sending (e.g. with a context).
+// The extra columns of an extras field are taken from the first record only.
+func (c *Writer[Record]) WriteFrom(ch <-chan Record) (err error) {
+	defer func() {
+		// The rows written before an error are still flushed
+		if flushErr := c.Flush(); err == nil {
+			err = flushErr
+		}
+	}()
+	var idx int
+	for item := range ch {
+		if !c.headerWritten {
+			if err := c.writeHeader([]Record{item}); err != nil {
+				return stack.Trace(err)
+			}
+		}
+		if err := c.writeItem(idx, item); err != nil {
+			return stack.Trace(err)
+		}
+		idx++
+		if c.FlushOnWrite && c.FlushBytesThreshold <= 0 && len(ch) == 0 {
+			if err := c.Flush(); err != nil {
 				return stack.Trace(err)
 			}
+		}
+	}
+	if !c.headerWritten {
+		// The header is still written for an empty channel, the same as WriteAll with no records
+		if err := c.writeHeader(nil); err != nil {
+			return stack.Trace(err)
+		}
+	}
+	return nil
+}
+
+// write writes a row to the underlying csv-writer, flushing once FlushBytesThreshold is exceeded.
+func (c *Writer[Record]) write(row []string) error {
+	if err := c.w.Write(row); err != nil {
+		return stack.Trace(err)
+	}
+	if c.FlushBytesThreshold <= 0 {
+		return nil
+	}
+	// csv.Writer doesn't expose its buffer, so count a separator or newline for each cell and ignore any quoting
+	for _, cell := range row {
+		c.bufferedBytes += len(cell) + 1
+	}
+	if c.bufferedBytes >= c.FlushBytesThreshold {
+		return stack.Trace(c.Flush())
+	}
+	return nil
+}
+
+// Flush writes any buffered data to the underlying io.Writer and reports any error from writing.
+func (c *Writer[Record]) Flush() error {
+	c.w.Flush()
+	c.bufferedBytes = 0
+	if err := c.w.Error(); err != nil {
+		return stack.Trace(err)
+	}
+	return nil
+}
+
+// Reset rebinds the writer to a new destination so the header is written again, keeping its options and cached field data.
+// The delimiter is carried over, anything still buffered for the old destination is dropped so Flush it first.
+func (c *Writer[Record]) Reset(writer io.Writer) {
+	w := csv.NewWriter(writer)
+	w.Comma = c.w.Comma
+	w.UseCRLF = c.w.UseCRLF
+	c.out = writer
+	c.w = w
+	c.headerWritten = false
+	c.extraColumns = nil
+	c.rowNumber = 0
+	c.bufferedBytes = 0
+}
+
+// encodeRecord encodes every field of a record into the cells for a row.
+// Pointer records are dereferenced, a nil record is written as a row of empty cells (or NullOutput).
+func (c *Writer[Record]) encodeRecord(item Record) ([]string, error) {
+	vOf := reflect.ValueOf(item)
+	width := len(c.fields) + len(c.extraColumns)
+	row := make([]string, 0, width)
+	if vOf.Kind() == reflect.Ptr {
+		if vOf.IsNil() {
+			// A nil record has no values, so every cell is null
+			for range width {
+				row = append(row, c.NullOutput)
+			}
+			return row, nil
+		}
+		vOf = vOf.Elem()
+	}
+	var extras reflect.Value
+	if c.extras != nil {
+		extras = c.extras.valueOf(vOf)
+	}
+	// written counts the extras keys that had a column
+	var written int
+	for idx, field := range c.fields {
+		if field == nil {
+			// Columns without a field are filled from the extras field if there is one
+			val, ok := extraValue(extras, c.columns[idx])
+			if ok {
+				written++
+			}
+			val, err := c.encodeExtra(c.columns[idx], val)
+			if err != nil {
+				return nil, stack.Trace(err)
+			}
 			row = append(row, val)
+			continue
+		}
+		fieldVal := field.valueOf(vOf)
+		val, err := field.InstructionData().encoder(fieldVal)
+		if err != nil {
+			return nil, stack.Trace(err)
+		}
+		if c.locale != nil && field.InstructionData().isLocalizable(fieldVal.Type()) {
+			val = c.locale.localizeCell(fieldVal, val)
+		}
+		if c.SanitizeFormulas && isStringKind(fieldVal.Type()) {
+			val = c.sanitizeFormula(val)
+		}
+		if encrypt, ok := c.encrypters[c.columns[idx]]; ok && len(val) > 0 {
+			if val, err = encrypt(val); err != nil {
+				return nil, stack.Trace(fmt.Errorf("encrypting %v: %w", c.columns[idx], err))
+			}
 		}
-		if err := c.w.Write(row); err != nil {
+		if len(val) == 0 && len(c.NullOutput) > 0 && isNullField(fieldVal) {
+			val = c.NullOutput
+		}
+		row = append(row, val)
+	}
+	for _, column := range c.extraColumns {
+		val, ok := extraValue(extras, column)
+		if ok {
+			written++
+		}
+		val, err := c.encodeExtra(column, val)
+		if err != nil {
+			return nil, stack.Trace(err)
+		}
+		row = append(row, val)
+	}
+	if extras.IsValid() && written != extras.Len() {
+		return nil, stack.Trace(fmt.Errorf("%v has the extras key %q which has no column, the extra columns are taken from the records in the first write", c.extras.InstructionData().structFieldName, c.missingExtraColumn(extras)))
+	}
+	return row, nil
+}
+
+// encodeExtra applies formula sanitizing and encryption to a cell from the extras field.
+func (c *Writer[Record]) encodeExtra(column, val string) (string, error) {
+	if c.SanitizeFormulas {
+		val = c.sanitizeFormula(val)
+	}
+	if encrypt, ok := c.encrypters[column]; ok && len(val) > 0 {
+		var err error
+		if val, err = encrypt(val); err != nil {
+			return "", fmt.Errorf("encrypting %v: %w", column, err)
+		}
+	}
+	return val, nil
+}
+
+// collectExtraColumns sets the extra columns to the union of the extras keys of the records, sorted so the header is stable.
+// Keys that already have a column are left out.
+func (c *Writer[Record]) collectExtraColumns(items []Record) {
+	if c.extras == nil {
+		return
+	}
+	seen := map[string]struct{}{}
+	for _, item := range items {
+		vOf := reflect.ValueOf(item)
+		if vOf.Kind() == reflect.Ptr {
+			if vOf.IsNil() {
+				continue
+			}
+			vOf = vOf.Elem()
+		}
+		for _, key := range extraKeys(c.extras.valueOf(vOf)) {
+			if _, ok := seen[key]; ok || slices.Contains(c.columns, key) {
+				continue
+			}
+			seen[key] = struct{}{}
+			c.extraColumns = append(c.extraColumns, key)
+		}
+	}
+	slices.Sort(c.extraColumns)
+}
+
+// missingExtraColumn gets the first extras key, in sorted order, that has no column to be written in.
+func (c *Writer[Record]) missingExtraColumn(extras reflect.Value) string {
+	for _, key := range extraKeys(extras) {
+		if slices.Contains(c.extraColumns, key) {
+			continue
+		}
+		if idx := slices.Index(c.columns, key); idx >= 0 && c.fields[idx] == nil {
+			continue
+		}
+		return key
+	}
+	return ""
+}
+
+// writeHeader is a helper method to write out the header to the CSV, in SparseKV or NoHeader mode only the BOM is written
+// The extra columns are collected from the records being written.
+func (c *Writer[Record]) writeHeader(items []Record) error {
+	if err := layoutError(c.instruction); err != nil {
+		return stack.Trace(err)
+	}
+	c.collectExtraColumns(items)
+	if c.WriteBOM {
+		// Nothing has been written yet, so the BOM can go straight to the destination
+		if _, err := io.WriteString(c.out, utf8BOM); err != nil {
 			return stack.Trace(err)
 		}
 	}
+	if !c.SparseKV && !c.NoHeader {
+		if err := c.write(c.headerColumns()); err != nil {
+			return stack.Trace(err)
+		}
+	}
+	c.headerWritten = true
 	return nil
 }
 
-// writeHeader is a helper method to write out the header to the CSV
-func (c *Writer[Record]) writeHeader() error {
+// headerColumns returns the header names including the row number column.
+func (c *Writer[Record]) headerColumns() []string {
 	var columns []string
-	var rec Record
-	for _, field := range fieldCache.GetTypeDataFor(reflect.TypeOf(rec)).Fields() {
-		columns = append(columns, field.InstructionData().GetCSVHeaderIdentifier())
+	if c.IncludeRowNumber {
+		if len(c.RowNumberColumn) > 0 {
+			columns = append(columns, c.RowNumberColumn)
+		} else {
+			columns = append(columns, "row_number")
+		}
+	}
+	columns = append(columns, c.columns...)
+	return append(columns, c.extraColumns...)
+}
+
+// sparseRow converts the cells of a row into field=value cells for SparseKV, dropping empty cells.
+func (c *Writer[Record]) sparseRow(row []string) []string {
+	columns := c.headerColumns()
+	out := make([]string, 0, len(row))
+	for idx, cell := range row {
+		if len(cell) == 0 {
+			continue
+		}
+		out = append(out, columns[idx]+"="+cell)
+	}
+	return out
+}
+
+// SetLocale applies the formatting conventions of a locale, such as LocaleDE, to encoding numbers, booleans, and dates.
+// This also sets the delimiter if the locale has one, so it must be called before the first write.
+func (c *Writer[Record]) SetLocale(locale Locale) {
+	c.locale = &locale
+	if locale.Delimiter != 0 {
+		c.w.Comma = locale.Delimiter
+	}
+}
+
+// SetDelimiter sets the field delimiter, such as ';' or '\t'.
+// Changing the delimiter mid-stream would corrupt the output, so this errors once the header has been written.
+func (c *Writer[Record]) SetDelimiter(delimiter rune) error {
+	if c.headerWritten {
+		return stack.Trace(errors.New("the delimiter can not be changed after the header has been written"))
+	}
+	c.w.Comma = delimiter
+	return nil
+}
+
+// SkipEmptyRows drops records whose fields all encode to empty strings (e.g. every field is omitempty and zero),
+// rather than writing a line of delimiters. Skipped records don't use up a row number for IncludeRowNumber.
+func (c *Writer[Record]) SkipEmptyRows(skip bool) {
+	c.skipEmptyRows = skip
+}
+
+// isEmptyRow checks if every cell of a row is empty.
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if len(cell) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SetCellEncrypter sets a hook that encrypts the cells of a column after they are encoded, such as for PII.
+// Null cells are left as-is, see Reader.SetCellDecrypter for the reverse.
+func (c *Writer[Record]) SetCellEncrypter(column string, fn func(string) (string, error)) {
+	if c.encrypters == nil {
+		c.encrypters = map[string]func(string) (string, error){}
+	}
+	c.encrypters[column] = fn
+}
+
+// sanitizeFormula prefixes a cell that would be treated as a formula so spreadsheets treat it as text.
+func (c *Writer[Record]) sanitizeFormula(val string) string {
+	if len(val) == 0 {
+		return val
+	}
+	switch val[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		if len(c.FormulaEscape) > 0 {
+			return c.FormulaEscape + val
+		}
+		return "'" + val
+	}
+	return val
+}
+
+// nullReporter is implemented by nullable types such as NullableField to report if they are unset.
+type nullReporter interface {
+	IsNull() bool
+}
+
+// isNullField checks if a field holds a nullable type that is unset.
+func isNullField(val reflect.Value) bool {
+	if !val.CanInterface() {
+		return false
+	}
+	nullable, ok := val.Interface().(nullReporter)
+	return ok && nullable.IsNull()
+}
+
+// isStringKind checks if a type (or the type it points to) is a string.
+func isStringKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String
+}
+
+// WriteMap writes the records of a map in the order of its keys sorted by less, so the output is deterministic.
+// Go methods can't have their own type parameters, so this takes the writer as an argument.
+// Like WriteAll, the writer is flushed once the records are written.
+func WriteMap[K comparable, Record any](c *Writer[Record], m map[K]Record, less func(a, b K) bool) error {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
-	if err := c.w.Write(columns); err != nil {
+	slices.SortFunc(keys, func(a, b K) int {
+		if less(a, b) {
+			return -1
+		} else if less(b, a) {
+			return 1
+		}
+		return 0
+	})
+	items := make([]Record, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, m[key])
+	}
+	if err := c.WriteAll(items); err != nil {
 		return stack.Trace(err)
 	}
-	c.headerWritten = true
 	return nil
 }
@@ -2,6 +2,11 @@ package csv
 
 import (
 	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
 	"testing"
 
 	testifyrequire "github.com/stretchr/testify/require"
@@ -33,7 +38,8 @@ func TestNewWriter(t *testing.T) {
 			ShouldBill: true,
 		})
 		require.NoError(err)
-		require.Equal("test@example.com,32,6512.23,TRUE\n", buf.String())
+		require.NoError(writer.Flush())
+		require.Equal("email,age,owed,\ntest@example.com,32,6512.23,TRUE\n", buf.String())
 	})
 	t.Run("Omit Empty", func(t *testing.T) {
 		require := testifyrequire.New(t)
@@ -46,6 +52,633 @@ func TestNewWriter(t *testing.T) {
 			ShouldBill: true,
 		})
 		require.NoError(err)
-		require.Equal("test@example.com,32,6512.23,TRUE\n", buf.String())
+		require.NoError(writer.Flush())
+		require.Equal("email,age,owed,\ntest@example.com,32,6512.23,TRUE\n", buf.String())
+	})
+}
+
+type failingMarshaller struct {
+	Fail bool
+}
+
+func (f failingMarshaller) MarshalCSV() (string, error) {
+	if f.Fail {
+		return "", errors.New("can not encode")
+	}
+	return "ok", nil
+}
+
+type testWriterFailingStruct struct {
+	Name   string            `csv:"name"`
+	Status failingMarshaller `csv:"status"`
+}
+
+func TestWriter_OnRowError(t *testing.T) {
+	records := []testWriterFailingStruct{
+		{Name: "first"},
+		{Name: "second", Status: failingMarshaller{Fail: true}},
+		{Name: "third"},
+	}
+	t.Run("skip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFailingStruct](&buf)
+		var skipped []int
+		writer.OnRowError = func(index int, record testWriterFailingStruct, err error) bool {
+			require.Equal(records[index], record)
+			require.EqualError(err, "can not encode")
+			skipped = append(skipped, index)
+			return true
+		}
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal([]int{1}, skipped)
+		require.Equal("name,status\nfirst,ok\nthird,ok\n", buf.String())
+	})
+	t.Run("abort", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFailingStruct](&buf)
+		writer.OnRowError = func(index int, record testWriterFailingStruct, err error) bool {
+			return false
+		}
+		require.EqualError(writer.WriteRecord(records...), "can not encode")
+		require.NoError(writer.Flush())
+		require.Equal("name,status\nfirst,ok\n", buf.String())
+	})
+	t.Run("no callback", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFailingStruct](&buf)
+		require.EqualError(writer.WriteRecord(records...), "can not encode")
+	})
+}
+
+type testWriterFormulaStruct struct {
+	Comment string  `csv:"comment"`
+	Balance float64 `csv:"balance"`
+}
+
+func TestWriter_SanitizeFormulas(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		comment  string
+		expected string
+	}{
+		{name: "equals", comment: "=SUM(A1:A2)", expected: "'=SUM(A1:A2)"},
+		{name: "plus", comment: "+1", expected: "'+1"},
+		{name: "minus", comment: "-1", expected: "'-1"},
+		{name: "at", comment: "@cmd", expected: "'@cmd"},
+		{name: "tab", comment: "\tcmd", expected: "'\tcmd"},
+		{name: "carriage return", comment: "\rcmd", expected: "\"'\rcmd\""},
+		{name: "safe", comment: "a=b", expected: "a=b"},
+		{name: "empty", comment: "", expected: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require := testifyrequire.New(t)
+			buf := bytes.Buffer{}
+			writer := NewWriter[testWriterFormulaStruct](&buf)
+			writer.SanitizeFormulas = true
+			require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: tc.comment, Balance: -5}))
+			require.NoError(writer.Flush())
+			require.Equal("comment,balance\n"+tc.expected+",-5\n", buf.String())
+		})
+	}
+	t.Run("custom escape", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		writer.SanitizeFormulas = true
+		writer.FormulaEscape = "\\"
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "=1+1"}))
+		require.NoError(writer.Flush())
+		require.Equal("comment,balance\n\\=1+1,0\n", buf.String())
+	})
+	t.Run("off by default", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "=1+1"}))
+		require.NoError(writer.Flush())
+		require.Equal("comment,balance\n=1+1,0\n", buf.String())
+	})
+}
+
+// groupedNumber formats with a grouping separator that can collide with the CSV delimiter
+type groupedNumber struct {
+	Value     int
+	Separator string
+}
+
+func (g groupedNumber) MarshalCSV() (string, error) {
+	digits := strconv.Itoa(g.Value)
+	var out []string
+	for len(digits) > 3 {
+		out = append([]string{digits[len(digits)-3:]}, out...)
+		digits = digits[:len(digits)-3]
+	}
+	return strings.Join(append([]string{digits}, out...), g.Separator), nil
+}
+
+type testWriterGroupedStruct struct {
+	Before string        `csv:"before"`
+	Amount groupedNumber `csv:"amount"`
+	After  string        `csv:"after"`
+}
+
+// csv.Writer quotes any cell containing the active delimiter, so a grouping separator never shifts the columns.
+func TestWriter_DelimiterInNumericCell(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		delimiter rune
+		separator string
+		expected  string
+	}{
+		{name: "comma grouping, comma delimited", delimiter: ',', separator: ",", expected: "before,amount,after\na,\"1,234,567\",b\n"},
+		{name: "semicolon grouping, comma delimited", delimiter: ',', separator: ";", expected: "before,amount,after\na,1;234;567,b\n"},
+		{name: "semicolon grouping, semicolon delimited", delimiter: ';', separator: ";", expected: "before;amount;after\na;\"1;234;567\";b\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require := testifyrequire.New(t)
+			buf := bytes.Buffer{}
+			writer := NewWriter[testWriterGroupedStruct](&buf)
+			writer.w.Comma = tc.delimiter
+			require.NoError(writer.WriteRecord(testWriterGroupedStruct{
+				Before: "a",
+				Amount: groupedNumber{Value: 1234567, Separator: tc.separator},
+				After:  "b",
+			}))
+			require.NoError(writer.Flush())
+			require.Equal(tc.expected, buf.String())
+
+			// The columns must still line up when read back
+			reader := csv.NewReader(&buf)
+			reader.Comma = tc.delimiter
+			rows, err := reader.ReadAll()
+			require.NoError(err)
+			require.Equal([]string{"a", "1" + tc.separator + "234" + tc.separator + "567", "b"}, rows[1])
+		})
+	}
+}
+
+func TestWriter_IncludeRowNumber(t *testing.T) {
+	t.Run("default column", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		writer.IncludeRowNumber = true
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "a"}, testWriterFormulaStruct{Comment: "b"}))
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "c"}))
+		require.NoError(writer.Flush())
+		require.Equal("row_number,comment,balance\n1,a,0\n2,b,0\n3,c,0\n", buf.String())
+	})
+	t.Run("custom column", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		writer.IncludeRowNumber = true
+		writer.RowNumberColumn = "line"
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "a"}))
+		require.NoError(writer.Flush())
+		require.Equal("line,comment,balance\n1,a,0\n", buf.String())
+	})
+}
+
+func TestNewWriterWithColumnOrder(t *testing.T) {
+	require := testifyrequire.New(t)
+	input := "a_bool,unknown,a_string,an_int\ntrue,x,string,11\nfalse,y,other,12\n"
+	reader := NewStructuredCSVReader[simpleCSVRecord](strings.NewReader(input))
+	records, err := readAllRecords(reader)
+	require.NoError(err)
+	require.Equal([]string{"a_bool", "unknown", "a_string", "an_int"}, reader.Headers())
+
+	buf := bytes.Buffer{}
+	writer := NewWriterWithColumnOrder[simpleCSVRecord](&buf, reader.Headers())
+	require.NoError(writer.WriteRecord(records...))
+	require.NoError(writer.Flush())
+	// a_float is omitted since it wasn't in the source, unknown is blanked since it has no field
+	require.Equal("a_bool,unknown,a_string,an_int\nTRUE,,string,11\nFALSE,,other,12\n", buf.String())
+}
+
+func TestWriter_WriteBOM(t *testing.T) {
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[testWriterFormulaStruct](&buf)
+	writer.WriteBOM = true
+	require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "é"}))
+	require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "ü"}))
+	require.NoError(writer.Flush())
+	require.Equal([]byte{0xEF, 0xBB, 0xBF}, buf.Bytes()[:3])
+	require.Equal("comment,balance\né,0\nü,0\n", buf.String()[3:])
+
+	t.Run("only before the header", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "a"}))
+		require.NoError(writer.Flush())
+		writer.WriteBOM = true
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "b"}))
+		require.NoError(writer.Flush())
+		require.Equal("comment,balance\na,0\nb,0\n", buf.String())
+
+		// A new destination gets its own mark
+		second := bytes.Buffer{}
+		writer.Reset(&second)
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "c"}))
+		require.NoError(writer.Flush())
+		require.Equal(utf8BOM+"comment,balance\nc,0\n", second.String())
+	})
+}
+
+func TestWriteMap(t *testing.T) {
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[testWriterFormulaStruct](&buf)
+	records := map[int]testWriterFormulaStruct{
+		3: {Comment: "c", Balance: 3},
+		1: {Comment: "a", Balance: 1},
+		2: {Comment: "b", Balance: 2},
+	}
+	require.NoError(WriteMap(writer, records, func(a, b int) bool { return a < b }))
+	require.Equal("comment,balance\na,1\nb,2\nc,3\n", buf.String())
+}
+
+func TestWriter_FlushBytesThreshold(t *testing.T) {
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[testWriterFormulaStruct](&buf)
+	writer.FlushBytesThreshold = 40
+	// The header is 16 bytes and each row is 8, so nothing is flushed until the third row
+	require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "aaaaa", Balance: 1}))
+	require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "bbbbb", Balance: 2}))
+	require.Empty(buf.String())
+	require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "ccccc", Balance: 3}))
+	require.Equal("comment,balance\naaaaa,1\nbbbbb,2\nccccc,3\n", buf.String())
+	require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "ddddd", Balance: 4}))
+	require.NoError(writer.Flush())
+	require.Equal("comment,balance\naaaaa,1\nbbbbb,2\nccccc,3\nddddd,4\n", buf.String())
+}
+
+func benchmarkWriterFlushing(b *testing.B, threshold int) {
+	writer := NewWriter[testWriterStruct](io.Discard)
+	writer.FlushBytesThreshold = threshold
+	record := testWriterStruct{Email: "test@example.com", Age: 42, Owed: 1234.56}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := writer.WriteRecord(record); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkWriter_PerRecordFlush(b *testing.B) {
+	benchmarkWriterFlushing(b, 0)
+}
+
+func BenchmarkWriter_FlushBytesThreshold(b *testing.B) {
+	benchmarkWriterFlushing(b, 64*1024)
+}
+
+func TestWriter_RowHook(t *testing.T) {
+	t.Run("modifies cells", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		writer.IncludeRowNumber = true
+		writer.RowHook = func(cells []string) ([]string, error) {
+			cells[1] = strings.Repeat("*", len(cells[1]))
+			return cells, nil
+		}
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "secret", Balance: 5}))
+		require.NoError(writer.Flush())
+		require.Equal("row_number,comment,balance\n1,******,5\n", buf.String())
+	})
+	t.Run("width mismatch", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[testWriterFormulaStruct](&bytes.Buffer{})
+		writer.RowHook = func(cells []string) ([]string, error) {
+			return append(cells, "suffix"), nil
+		}
+		require.EqualError(writer.WriteRecord(testWriterFormulaStruct{}), "row hook returned 3 cells but the header has 2")
+	})
+	t.Run("error", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[testWriterFormulaStruct](&bytes.Buffer{})
+		writer.RowHook = func(cells []string) ([]string, error) {
+			return nil, errors.New("redaction failed")
+		}
+		require.EqualError(writer.WriteRecord(testWriterFormulaStruct{}), "redaction failed")
+	})
+}
+
+func TestWriter_SparseKV(t *testing.T) {
+	type sparseRecord struct {
+		Email  string  `csv:"email,omitempty"`
+		Age    int     `csv:"age,omitempty"`
+		Owed   float64 `csv:"owed,omitempty"`
+		Active bool    `csv:"active"`
+	}
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[sparseRecord](&buf)
+	writer.SparseKV = true
+	require.NoError(writer.WriteRecord(
+		sparseRecord{Email: "a@example.com", Owed: 1.5},
+		sparseRecord{Age: 30, Active: true},
+	))
+	require.NoError(writer.Flush())
+	require.Equal("email=a@example.com,owed=1.5,active=FALSE\nage=30,active=TRUE\n", buf.String())
+}
+
+func TestWriter_SetDelimiter(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		require.NoError(writer.SetDelimiter('\t'))
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "a,b", Balance: 1.5}))
+		require.NoError(writer.Flush())
+		require.Equal("comment\tbalance\na,b\t1.5\n", buf.String())
+
+		reader := NewStructuredCSVReader[testWriterFormulaStruct](&buf)
+		require.NoError(reader.SetDelimiter('\t'))
+		record, err := reader.Next()
+		require.NoError(err)
+		require.Equal(testWriterFormulaStruct{Comment: "a,b", Balance: 1.5}, record)
+	})
+	t.Run("after the header", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[testWriterFormulaStruct](&bytes.Buffer{})
+		require.NoError(writer.WriteRecord())
+		require.EqualError(writer.SetDelimiter(';'), "the delimiter can not be changed after the header has been written")
+	})
+}
+
+// failingWriter errors on every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriter_WriteAll(t *testing.T) {
+	t.Run("writes every record", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		writer.FlushBytesThreshold = 1 << 20
+		require.NoError(writer.WriteAll([]testWriterFormulaStruct{{Comment: "a", Balance: 1}, {Comment: "b", Balance: 2}}))
+		// Flushed even though the threshold was not reached
+		require.Equal("comment,balance\na,1\nb,2\n", buf.String())
+	})
+	t.Run("flush error", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[testWriterFormulaStruct](failingWriter{})
+		require.EqualError(writer.WriteAll([]testWriterFormulaStruct{{Comment: "a"}}), "disk full")
+	})
+}
+
+func TestWriter_FlushOnWrite(t *testing.T) {
+	t.Run("off by default", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		require.False(writer.FlushOnWrite)
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "a", Balance: 1}))
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "b", Balance: 2}))
+		require.Empty(buf.String())
+		require.NoError(writer.Flush())
+		require.Equal("comment,balance\na,1\nb,2\n", buf.String())
+	})
+	t.Run("enabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[testWriterFormulaStruct](&buf)
+		writer.FlushOnWrite = true
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "a", Balance: 1}))
+		require.Equal("comment,balance\na,1\n", buf.String())
+		require.NoError(writer.WriteRecord(testWriterFormulaStruct{Comment: "b", Balance: 2}))
+		require.Equal("comment,balance\na,1\nb,2\n", buf.String())
+	})
+}
+
+func TestWriter_NoHeader(t *testing.T) {
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[simpleCSVRecord](&buf)
+	writer.NoHeader = true
+	require.NoError(writer.WriteRecord(simpleCSVRecord{AString: "a", AFloat: 1.5, AnInt: 2, ABool: true}))
+	require.NoError(writer.WriteRecord(simpleCSVRecord{AString: "b"}))
+	require.NoError(writer.Flush())
+	require.Equal("a,1.5,2,TRUE\nb,0,0,FALSE\n", buf.String())
+
+	// The output reads back by position
+	reader := NewStructuredCSVReader[simpleCSVRecord](&buf)
+	reader.NoHeader = true
+	records, err := reader.ReadAll()
+	require.NoError(err)
+	require.Equal([]simpleCSVRecord{{AString: "a", AFloat: 1.5, AnInt: 2, ABool: true}, {AString: "b"}}, records)
+}
+
+func TestWriter_ColumnIndex(t *testing.T) {
+	type indexedRecord struct {
+		Notes string `csv:"notes"`
+		Name  string `csv:"name,index=2"`
+		ID    int    `csv:"id,index=1"`
+		Extra string `csv:"extra"`
+	}
+	type duplicateIndexRecord struct {
+		A string `csv:"a,index=1"`
+		B string `csv:"b,index=1"`
+	}
+	type invalidIndexRecord struct {
+		A string `csv:"a,index=first"`
+	}
+	t.Run("indexed columns first", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[indexedRecord](&buf)
+		require.NoError(writer.WriteRecord(indexedRecord{Notes: "n", Name: "alice", ID: 7, Extra: "e"}))
+		require.NoError(writer.Flush())
+		require.Equal("id,name,notes,extra\n7,alice,n,e\n", buf.String())
+		names := make([]string, 0, 4)
+		for _, column := range DescribeColumns[indexedRecord]() {
+			names = append(names, column.Name)
+		}
+		require.Equal([]string{"id", "name", "notes", "extra"}, names)
+	})
+	t.Run("duplicate index", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[duplicateIndexRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(duplicateIndexRecord{}), "a and b both use index=1")
+	})
+	t.Run("invalid index", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		writer := NewWriter[invalidIndexRecord](&bytes.Buffer{})
+		require.EqualError(writer.WriteRecord(invalidIndexRecord{}), `a has an invalid index= value "first"`)
+	})
+}
+
+func TestWriter_SkipEmptyRows(t *testing.T) {
+	type sparseRecord struct {
+		Name  string `csv:"name,omitempty"`
+		Count int    `csv:"count,omitempty"`
+	}
+	records := []sparseRecord{{Name: "a"}, {}, {Count: 2}}
+	t.Run("enabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[sparseRecord](&buf)
+		writer.SkipEmptyRows(true)
+		writer.IncludeRowNumber = true
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("row_number,name,count\n1,a,\n2,,2\n", buf.String())
+	})
+	t.Run("disabled", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[sparseRecord](&buf)
+		require.NoError(writer.WriteRecord(records...))
+		require.NoError(writer.Flush())
+		require.Equal("name,count\na,\n,\n,2\n", buf.String())
+	})
+}
+
+func TestWriter_NullOutput(t *testing.T) {
+	type nullOutputRecord struct {
+		Name  NullableField[string] `csv:"name"`
+		Count NullableField[int]    `csv:"count"`
+		Plain string                `csv:"plain"`
+	}
+	var empty NullableField[string]
+	empty.Set("")
+	var count NullableField[int]
+	count.Set(0)
+	require := testifyrequire.New(t)
+	buf := bytes.Buffer{}
+	writer := NewWriter[nullOutputRecord](&buf)
+	writer.NullOutput = `\N`
+	require.NoError(writer.WriteRecord(
+		nullOutputRecord{},
+		nullOutputRecord{Name: empty, Count: count},
+	))
+	require.NoError(writer.Flush())
+	// Only unset nullables are written as the sentinel, an empty string and a plain field stay empty
+	require.Equal("name,count,plain\n\\N,\\N,\n,0,\n", buf.String())
+
+	reader := NewStructuredCSVReader[nullOutputRecord](&buf)
+	reader.NullValues = []string{`\N`}
+	record, err := reader.Next()
+	require.NoError(err)
+	require.True(record.Name.IsNull())
+	require.True(record.Count.IsNull())
+}
+
+func TestWriter_Reset(t *testing.T) {
+	require := testifyrequire.New(t)
+	first := bytes.Buffer{}
+	writer := NewWriter[simpleCSVRecord](&first)
+	writer.IncludeRowNumber = true
+	require.NoError(writer.SetDelimiter(';'))
+	require.NoError(writer.WriteRecord(simpleCSVRecord{AString: "a"}, simpleCSVRecord{AString: "b"}))
+	require.NoError(writer.Flush())
+
+	second := bytes.Buffer{}
+	writer.Reset(&second)
+	require.NoError(writer.WriteRecord(simpleCSVRecord{AString: "c"}))
+	require.NoError(writer.Flush())
+	require.Equal("row_number;a_string;a_float;an_int;a_bool\n1;a;0;0;FALSE\n2;b;0;0;FALSE\n", first.String())
+	// The header and row numbers start over, the delimiter is kept
+	require.Equal("row_number;a_string;a_float;an_int;a_bool\n1;c;0;0;FALSE\n", second.String())
+	// The delimiter can be changed again until the header is written
+	writer.Reset(&bytes.Buffer{})
+	require.NoError(writer.SetDelimiter(','))
+}
+
+func TestWriter_PointerRecords(t *testing.T) {
+	t.Run("value records", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[simpleCSVRecord](&buf)
+		require.NoError(writer.WriteRecord(simpleCSVRecord{AString: "a", AnInt: 1}))
+		require.NoError(writer.Flush())
+		require.Equal("a_string,a_float,an_int,a_bool\na,0,1,FALSE\n", buf.String())
+	})
+	t.Run("pointer records", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[*simpleCSVRecord](&buf)
+		require.NoError(writer.WriteRecord(&simpleCSVRecord{AString: "a", AnInt: 1}, nil, &simpleCSVRecord{AString: "b", ABool: true}))
+		require.NoError(writer.Flush())
+		// A nil record is written as a row of empty cells
+		require.Equal("a_string,a_float,an_int,a_bool\na,0,1,FALSE\n,,,\nb,0,0,TRUE\n", buf.String())
+	})
+	t.Run("nil records with null output", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[*simpleCSVRecord](&buf)
+		writer.NullOutput = `\N`
+		require.NoError(writer.WriteRecord(nil, &simpleCSVRecord{AString: "a"}))
+		require.NoError(writer.Flush())
+		require.Equal("a_string,a_float,an_int,a_bool\n\\N,\\N,\\N,\\N\na,0,0,FALSE\n", buf.String())
+	})
+}
+
+func TestWriter_WriteFrom(t *testing.T) {
+	t.Run("drains the channel", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[simpleCSVRecord](&buf)
+		writer.IncludeRowNumber = true
+		ch := make(chan simpleCSVRecord)
+		go func() {
+			defer close(ch)
+			for _, name := range []string{"a", "b", "c"} {
+				ch <- simpleCSVRecord{AString: name}
+			}
+		}()
+		require.NoError(writer.WriteFrom(ch))
+		require.Equal("row_number,a_string,a_float,an_int,a_bool\n1,a,0,0,FALSE\n2,b,0,0,FALSE\n3,c,0,0,FALSE\n", buf.String())
+	})
+	t.Run("empty channel", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[simpleCSVRecord](&buf)
+		ch := make(chan simpleCSVRecord)
+		close(ch)
+		require.NoError(writer.WriteFrom(ch))
+		require.Equal("a_string,a_float,an_int,a_bool\n", buf.String())
+	})
+	t.Run("first error", func(t *testing.T) {
+		require := testifyrequire.New(t)
+		buf := bytes.Buffer{}
+		writer := NewWriter[simpleCSVRecord](&buf)
+		var seen []int
+		writer.RowHook = func(cells []string) ([]string, error) {
+			if cells[0] == "bad" {
+				return nil, errors.New("bad row")
+			}
+			return cells, nil
+		}
+		writer.OnRowError = func(index int, record simpleCSVRecord, err error) bool {
+			seen = append(seen, index)
+			return true
+		}
+		ch := make(chan simpleCSVRecord, 3)
+		ch <- simpleCSVRecord{AString: "a"}
+		ch <- simpleCSVRecord{AString: "bad"}
+		ch <- simpleCSVRecord{AString: "c"}
+		close(ch)
+		require.EqualError(writer.WriteFrom(ch), "bad row")
+		// RowHook errors aren't passed to OnRowError, the rows before it are still flushed
+		require.Empty(seen)
+		require.Equal("a_string,a_float,an_int,a_bool\na,0,0,FALSE\n", buf.String())
+		require.Len(ch, 1)
 	})
 }
@@ -0,0 +1,35 @@
+package csv
+
+import (
+	"reflect"
+	"sync"
+)
+
+// zeroFuncRegistry holds the zero detection registered with RegisterZeroFunc for each type.
+var zeroFuncRegistry = struct {
+	mu    sync.RWMutex
+	funcs map[reflect.Type]func(reflect.Value) bool
+}{funcs: map[reflect.Type]func(reflect.Value) bool{}}
+
+// RegisterZeroFunc sets how omitempty decides if a value of type t is empty, in place of Zeroer or reflect.Value.IsZero.
+// This is useful for types that are empty in a domain specific way, e.g. a Money with an amount of 0 in any currency.
+// Registering the same type again replaces its function.
+func RegisterZeroFunc(t reflect.Type, fn func(reflect.Value) bool) {
+	zeroFuncRegistry.mu.Lock()
+	defer zeroFuncRegistry.mu.Unlock()
+	zeroFuncRegistry.funcs[t] = fn
+}
+
+// getRegisteredZeroFunc wraps a zero detection function to use the function registered for the type, if there is one.
+// The registry is checked on every call so types can be registered after their encoders are built.
+func getRegisteredZeroFunc(fieldType reflect.Type, fallback zeroValueFunction) zeroValueFunction {
+	return func(value reflect.Value) bool {
+		zeroFuncRegistry.mu.RLock()
+		fn, ok := zeroFuncRegistry.funcs[fieldType]
+		zeroFuncRegistry.mu.RUnlock()
+		if ok {
+			return fn(value)
+		}
+		return fallback(value)
+	}
+}
@@ -0,0 +1,52 @@
+package csv
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"testing"
+
+	testifyrequire "github.com/stretchr/testify/require"
+)
+
+type zeroTestMoney struct {
+	Currency string
+	Amount   int
+}
+
+func (m zeroTestMoney) MarshalCSV() (string, error) {
+	return m.Currency + " " + strconv.Itoa(m.Amount), nil
+}
+
+type zeroFuncRecord struct {
+	Name    string        `csv:"name"`
+	Balance zeroTestMoney `csv:"balance,omitempty"`
+}
+
+func TestRegisterZeroFunc(t *testing.T) {
+	require := testifyrequire.New(t)
+	records := []zeroFuncRecord{
+		{Name: "a", Balance: zeroTestMoney{Currency: "USD", Amount: 0}},
+		{Name: "b", Balance: zeroTestMoney{Currency: "USD", Amount: 5}},
+	}
+	buf := bytes.Buffer{}
+	writer := NewWriter[zeroFuncRecord](&buf)
+	require.NoError(writer.WriteRecord(records...))
+	require.NoError(writer.Flush())
+	// Without a zero func the struct is not zero since it has a currency
+	require.Equal("name,balance\na,USD 0\nb,USD 5\n", buf.String())
+
+	RegisterZeroFunc(reflect.TypeFor[zeroTestMoney](), func(v reflect.Value) bool {
+		return v.Interface().(zeroTestMoney).Amount == 0
+	})
+	defer func() {
+		zeroFuncRegistry.mu.Lock()
+		delete(zeroFuncRegistry.funcs, reflect.TypeFor[zeroTestMoney]())
+		zeroFuncRegistry.mu.Unlock()
+	}()
+	buf.Reset()
+	writer = NewWriter[zeroFuncRecord](&buf)
+	require.NoError(writer.WriteRecord(records...))
+	require.NoError(writer.Flush())
+	require.Equal("name,balance\na,\nb,USD 5\n", buf.String())
+}